@@ -0,0 +1,97 @@
+package messageboard
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Board groups messages, every message belongs to a board.
+type Board struct {
+	// ID is also the name of the board used in the urls.
+	ID           string     `json:"id" bson:"_id"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description,omitempty"`
+	Visibility   Visibility `json:"visibility"`
+	CreationTime time.Time  `json:"creation_time" bson:"creation_time"`
+}
+
+// Visibility defines who is able to post messages in a board.
+type Visibility string
+
+const (
+	// VisibilityPublic boards accept messages from anyone.
+	VisibilityPublic Visibility = "public"
+	// VisibilityPrivate boards accept messages only from authenticated users.
+	VisibilityPrivate Visibility = "private"
+)
+
+// DefaultBoardID is the board of the messages created without board, it always exists.
+const DefaultBoardID = "default"
+
+// DefaultBoard returns the default board, as it is until someone updates it.
+func DefaultBoard() *Board {
+	return &Board{
+		ID:         DefaultBoardID,
+		Title:      "Message Board",
+		Visibility: VisibilityPublic,
+	}
+}
+
+const (
+	MaxBoardIDLength          = 50
+	MaxBoardTitleLength       = 100
+	MaxBoardDescriptionLength = 1000
+)
+
+var boardIDRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Validate normalizes and validates the board, reporting all invalid fields at once.
+func (b *Board) Validate() error {
+	var errs FieldErrors
+
+	b.ID = strings.TrimSpace(b.ID)
+	switch {
+	case b.ID == "":
+		errs.Add("id", "missing_id", `field "id" is missing`)
+	case len(b.ID) > MaxBoardIDLength:
+		errs.Add("id", "id_too_long", fmt.Sprintf(`field "id" must have at most %d characters`, MaxBoardIDLength))
+	case !boardIDRegexp.MatchString(b.ID):
+		errs.Add("id", "invalid_id", `field "id" must have only lowercase letters, digits and "-", starting with a letter or digit`)
+	}
+
+	b.Title = strings.TrimSpace(b.Title)
+	switch {
+	case b.Title == "":
+		errs.Add("title", "missing_title", `field "title" is missing`)
+	case utf8.RuneCountInString(b.Title) > MaxBoardTitleLength:
+		errs.Add("title", "title_too_long", fmt.Sprintf(`field "title" must have at most %d characters`, MaxBoardTitleLength))
+	case !validChars(b.Title, false):
+		errs.Add("title", "invalid_title", `field "title" has invalid characters`)
+	}
+
+	b.Description = strings.TrimSpace(b.Description)
+	switch {
+	case utf8.RuneCountInString(b.Description) > MaxBoardDescriptionLength:
+		errs.Add("description", "description_too_long", fmt.Sprintf(`field "description" must have at most %d characters`, MaxBoardDescriptionLength))
+	case !validChars(b.Description, true):
+		errs.Add("description", "invalid_description", `field "description" has invalid characters`)
+	}
+
+	switch b.Visibility {
+	case "":
+		b.Visibility = VisibilityPublic
+	case VisibilityPublic, VisibilityPrivate:
+	default:
+		errs.Add("visibility", "invalid_visibility", `field "visibility" must be public or private`)
+	}
+	return errs.Err()
+}
+
+// BoardList is the list of boards, sorted by id.
+type BoardList struct {
+	Total uint     `json:"total"`
+	Data  []*Board `json:"data"`
+}
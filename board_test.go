@@ -0,0 +1,83 @@
+package messageboard_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoard_Validate(t *testing.T) {
+	b := &messageboard.Board{
+		ID:          " golang ",
+		Title:       " Golang ",
+		Description: "Everything about Go\n",
+	}
+	err := b.Validate()
+	assert.NoError(t, err)
+	assert.Equal(t, "golang", b.ID)
+	assert.Equal(t, "Golang", b.Title)
+	assert.Equal(t, "Everything about Go", b.Description)
+	assert.Equal(t, messageboard.VisibilityPublic, b.Visibility)
+}
+
+func TestBoard_ValidateInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		board  *messageboard.Board
+		fields []*messageboard.FieldError
+	}{
+		{
+			name:  "missing all fields",
+			board: &messageboard.Board{ID: " ", Title: "\n"},
+			fields: []*messageboard.FieldError{
+				{Field: "id", Code: "missing_id", Message: `field "id" is missing`},
+				{Field: "title", Code: "missing_title", Message: `field "title" is missing`},
+			},
+		},
+		{
+			name: "too long",
+			board: &messageboard.Board{
+				ID:          strings.Repeat("a", messageboard.MaxBoardIDLength+1),
+				Title:       strings.Repeat("á", messageboard.MaxBoardTitleLength+1),
+				Description: strings.Repeat("á", messageboard.MaxBoardDescriptionLength+1),
+			},
+			fields: []*messageboard.FieldError{
+				{Field: "id", Code: "id_too_long", Message: `field "id" must have at most 50 characters`},
+				{Field: "title", Code: "title_too_long", Message: `field "title" must have at most 100 characters`},
+				{Field: "description", Code: "description_too_long", Message: `field "description" must have at most 1000 characters`},
+			},
+		},
+		{
+			name: "invalid characters",
+			board: &messageboard.Board{
+				ID:          "Go Lang",
+				Title:       "Go\nlang",
+				Description: "Go\x00",
+				Visibility:  "hidden",
+			},
+			fields: []*messageboard.FieldError{
+				{Field: "id", Code: "invalid_id", Message: `field "id" must have only lowercase letters, digits and "-", starting with a letter or digit`},
+				{Field: "title", Code: "invalid_title", Message: `field "title" has invalid characters`},
+				{Field: "description", Code: "invalid_description", Message: `field "description" has invalid characters`},
+				{Field: "visibility", Code: "invalid_visibility", Message: `field "visibility" must be public or private`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.board.Validate()
+
+			var mberr *messageboard.Error
+			require.True(t, errors.As(err, &mberr), "expected *messageboard.Error, got: %v", err)
+			assert.Equal(t, messageboard.CategoryValidation, mberr.Category)
+			assert.Equal(t, "invalid_fields", mberr.Code)
+			assert.Equal(t, tt.fields, []*messageboard.FieldError(mberr.Fields))
+		})
+	}
+}
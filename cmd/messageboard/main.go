@@ -2,16 +2,21 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/guilherme-santos/messageboard"
+	"github.com/guilherme-santos/messageboard/filter"
 	mbhttp "github.com/guilherme-santos/messageboard/http"
+	"github.com/guilherme-santos/messageboard/memory"
 	"github.com/guilherme-santos/messageboard/mongodb"
 
 	"github.com/go-chi/chi"
@@ -21,10 +26,49 @@ import (
 )
 
 type Config struct {
-	HTTPAddr          string
-	Credentials       map[string]string
-	MongoDBURL        string
-	MongoDBInitialCSV string
+	HTTPAddr    string
+	Credentials map[string]string
+	Storage     string
+	InitialCSV  string
+	MongoDBURL  string
+	// TrashRetention is how long deleted messages stay in the trash.
+	TrashRetention time.Duration
+	ErrorFormat    mbhttp.ErrorFormat
+	// MaxThreadDepth is the maximum depth of the replies returned by the thread endpoint.
+	MaxThreadDepth int
+	// TenantHeader, TenantDomain and TenantUsers are the ways to resolve the tenant of the requests.
+	TenantHeader string
+	TenantDomain string
+	TenantUsers  map[string]string
+	// Tenants are the tenants the requests can use, besides the default one and
+	// the ones in TenantUsers.
+	Tenants []string
+	// MongoDBTenancy is how the tenants are kept apart in MongoDB.
+	MongoDBTenancy mongodb.Tenancy
+	// BannedWords, MaxLinks, MaxRepeatedChars and SpamCSV configure the content
+	// filters, they are disabled when empty or zero.
+	BannedWords      []string
+	MaxLinks         int
+	MaxRepeatedChars int
+	SpamCSV          string
+	// RateLimitBurst is how many messages a client can create in a row, and
+	// RateLimitInterval how long it takes to create one more.
+	RateLimitBurst    int
+	RateLimitInterval time.Duration
+	// RateLimitKey is how the clients are identified, ip or email.
+	RateLimitKey string
+	// DuplicateWindow is how long a message is checked for duplicates, and
+	// DuplicatePolicy what happens with them.
+	DuplicateWindow time.Duration
+	DuplicatePolicy messageboard.DuplicatePolicy
+	// IdempotencyTTL is how long the Idempotency-Key of the requests is kept.
+	IdempotencyTTL time.Duration
+	// ChallengeDifficulty is the difficulty of the challenges anonymous clients
+	// solve before posting, they are disabled when it's zero. ChallengeSecret
+	// signs them and ChallengeTTL is how long they can be solved.
+	ChallengeDifficulty int
+	ChallengeSecret     []byte
+	ChallengeTTL        time.Duration
 }
 
 var cfg Config
@@ -42,23 +86,55 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	mgoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoDBURL))
-	if err != nil {
-		log.Println("unable to connect to mongodb:", err)
-		return
+	var storage interface {
+		messageboard.Storage
+		messageboard.IdempotencyStore
+		LoadCSV(string) error
+		PurgeTrash(context.Context, time.Time) (int64, error)
+	}
+
+	switch cfg.Storage {
+	case "memory":
+		storage = memory.NewMessageBoardStorage()
+	default:
+		mgoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoDBURL))
+		if err != nil {
+			log.Println("unable to connect to mongodb:", err)
+			return
+		}
+		defer mgoClient.Disconnect(context.Background())
+
+		mgoStorage := mongodb.NewMessageBoardStorage(mgoClient, mongodb.WithTenancy(cfg.MongoDBTenancy))
+		err = mgoStorage.CreateIndexes(ctx)
+		if err != nil {
+			log.Println("unable to create mongodb indexes:", err)
+			return
+		}
+		storage = mgoStorage
+	}
+
+	if cfg.InitialCSV != "" {
+		log.Println("loading csv file", cfg.InitialCSV)
+		err := storage.LoadCSV(cfg.InitialCSV)
+		if err != nil {
+			log.Println("unable to load csv file:", err)
+			return
+		}
 	}
-	defer mgoClient.Disconnect(context.Background())
 
-	storage := mongodb.NewMessageBoardStorage(mgoClient)
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go purgeTrash(purgeCtx, storage, cfg.TrashRetention)
 
-	log.Println("loading csv file", cfg.MongoDBInitialCSV)
-	err = storage.LoadCSV(cfg.MongoDBInitialCSV)
+	filters, err := contentFilters(cfg)
 	if err != nil {
-		log.Println("unable to load csv file:", err)
+		log.Println("unable to create content filters:", err)
 		return
 	}
-
-	svc := messageboard.NewService(storage)
+	svc := messageboard.NewService(storage,
+		messageboard.WithContentFilters(filters...),
+		messageboard.WithDuplicateDetection(cfg.DuplicateWindow, cfg.DuplicatePolicy),
+	)
 
 	// I'm using go-chi because it's lightweight (https://github.com/go-chi/chi#benchmarks) and simple
 	// I usually reconfigure it, with nice logger and middlewares and so on,
@@ -66,9 +142,42 @@ func main() {
 	router := chi.NewRouter()
 	router.Use(middleware.Recoverer)
 
+	var tenantResolvers []mbhttp.TenantResolver
+	// Users are always resolved, even without TENANT_USERS, so the ones without
+	// tenant can't choose another one through the header or the subdomain.
+	if len(cfg.TenantUsers) > 0 || cfg.TenantHeader != "" || cfg.TenantDomain != "" {
+		tenantResolvers = append(tenantResolvers, mbhttp.TenantFromCredentials(cfg.Credentials, cfg.TenantUsers))
+	}
+	if cfg.TenantHeader != "" {
+		tenantResolvers = append(tenantResolvers, mbhttp.TenantFromHeader(cfg.TenantHeader))
+	}
+	if cfg.TenantDomain != "" {
+		tenantResolvers = append(tenantResolvers, mbhttp.TenantFromSubdomain(cfg.TenantDomain))
+	}
+
+	handlerOpts := []mbhttp.HandlerOption{
+		mbhttp.WithErrorFormat(cfg.ErrorFormat),
+		mbhttp.WithMaxThreadDepth(cfg.MaxThreadDepth),
+		mbhttp.WithTenantResolvers(tenantResolvers...),
+		mbhttp.WithKnownTenants(cfg.Tenants...),
+		mbhttp.WithIdempotencyStore(storage, cfg.IdempotencyTTL),
+	}
+	if cfg.RateLimitBurst > 0 {
+		key := mbhttp.RateLimitByIP
+		if cfg.RateLimitKey == "email" {
+			key = mbhttp.RateLimitByEmail
+		}
+		limiter := mbhttp.NewTokenBucket(cfg.RateLimitBurst, cfg.RateLimitInterval)
+		handlerOpts = append(handlerOpts, mbhttp.WithRateLimiter(limiter, key))
+	}
+	if cfg.ChallengeDifficulty > 0 {
+		pow := mbhttp.NewProofOfWork(cfg.ChallengeSecret, cfg.ChallengeDifficulty, cfg.ChallengeTTL, mbhttp.NewMemoryReplayCache())
+		handlerOpts = append(handlerOpts, mbhttp.WithProofOfWork(pow))
+	}
+
 	// Register message board handler to the router
 	mbhttp.NewPingHandler(router)
-	mbhttp.NewMessageBoardHandler(router, svc, cfg.Credentials)
+	mbhttp.NewMessageBoardHandler(router, svc, cfg.Credentials, handlerOpts...)
 
 	httpServer := &http.Server{
 		Addr:    cfg.HTTPAddr,
@@ -102,6 +211,54 @@ func main() {
 	httpServer.Shutdown(context.Background())
 }
 
+// purgeTrash permanently removes, from time to time, the messages which are in
+// the trash for longer than retention. It runs until ctx is done.
+func purgeTrash(ctx context.Context, storage interface {
+	PurgeTrash(context.Context, time.Time) (int64, error)
+}, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		n, err := storage.PurgeTrash(ctx, time.Now().UTC().Add(-retention))
+		if err != nil {
+			log.Println("unable to purge trash:", err)
+		} else if n > 0 {
+			log.Printf("%d message(s) purged from the trash", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// contentFilters returns the content filters enabled in cfg.
+func contentFilters(cfg Config) ([]messageboard.ContentFilter, error) {
+	var filters []messageboard.ContentFilter
+	if len(cfg.BannedWords) > 0 {
+		filters = append(filters, filter.NewBannedWords(cfg.BannedWords...))
+	}
+	if cfg.MaxLinks > 0 {
+		filters = append(filters, filter.NewLinkLimit(cfg.MaxLinks))
+	}
+	if cfg.MaxRepeatedChars > 0 {
+		filters = append(filters, filter.NewRepeatedChars(cfg.MaxRepeatedChars))
+	}
+	if cfg.SpamCSV != "" {
+		log.Println("training spam filter with", cfg.SpamCSV)
+		bayes := filter.NewBayes()
+		err := bayes.LoadCSV(cfg.SpamCSV)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, bayes)
+	}
+	return filters, nil
+}
+
 // loadConfig maps envvars to Config.
 func loadConfig(cfg *Config) error {
 	cfg.HTTPAddr = os.Getenv("HTTP_ADDR")
@@ -109,32 +266,205 @@ func loadConfig(cfg *Config) error {
 		cfg.HTTPAddr = "0.0.0.0:80"
 	}
 
-	cfg.Credentials = make(map[string]string)
+	cfg.Credentials = loadUsers("CREDENTIALS")
+
+	cfg.Storage = os.Getenv("STORAGE")
+	if cfg.Storage == "" {
+		cfg.Storage = "mongodb"
+	}
+	if cfg.Storage != "mongodb" && cfg.Storage != "memory" {
+		return fmt.Errorf("invalid STORAGE %q, use mongodb or memory", cfg.Storage)
+	}
+
+	cfg.InitialCSV = os.Getenv("INITIAL_CSV")
+	if cfg.InitialCSV == "" {
+		// Keep compatibility with the old envvar
+		cfg.InitialCSV = os.Getenv("MONGODB_INITIAL_CSV")
+	}
+
+	cfg.MongoDBURL = os.Getenv("MONGODB_URL")
+
+	switch v := os.Getenv("ERROR_FORMAT"); v {
+	case "", "json":
+		cfg.ErrorFormat = mbhttp.ErrorFormatJSON
+	case "problem":
+		cfg.ErrorFormat = mbhttp.ErrorFormatProblem
+	default:
+		return fmt.Errorf("invalid ERROR_FORMAT %q, use json or problem", v)
+	}
+
+	cfg.TrashRetention = 30 * 24 * time.Hour
+	if v := os.Getenv("TRASH_RETENTION"); v != "" {
+		retention, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid TRASH_RETENTION: %v", err)
+		}
+		cfg.TrashRetention = retention
+	}
+
+	cfg.MaxThreadDepth = mbhttp.DefaultMaxThreadDepth
+	if v := os.Getenv("THREAD_MAX_DEPTH"); v != "" {
+		depth, err := strconv.Atoi(v)
+		if err != nil || depth < 0 {
+			return fmt.Errorf("invalid THREAD_MAX_DEPTH %q, it must be a positive number", v)
+		}
+		cfg.MaxThreadDepth = depth
+	}
+
+	cfg.TenantHeader = os.Getenv("TENANT_HEADER")
+	cfg.TenantDomain = os.Getenv("TENANT_DOMAIN")
+	cfg.TenantUsers = loadUsers("TENANT_USERS")
+	for user, tenant := range cfg.TenantUsers {
+		if !messageboard.ValidTenant(tenant) {
+			return fmt.Errorf("invalid tenant %q in TENANT_USERS for user %q", tenant, user)
+		}
+		cfg.Tenants = append(cfg.Tenants, tenant)
+	}
+	for _, tenant := range strings.Split(os.Getenv("TENANTS"), ",") {
+		if tenant = strings.TrimSpace(tenant); tenant == "" {
+			continue
+		}
+		if !messageboard.ValidTenant(tenant) {
+			return fmt.Errorf("invalid tenant %q in TENANTS", tenant)
+		}
+		cfg.Tenants = append(cfg.Tenants, tenant)
+	}
+
+	switch v := os.Getenv("MONGODB_TENANCY"); v {
+	case "", "database":
+		cfg.MongoDBTenancy = mongodb.TenantDatabase
+	case "collection":
+		cfg.MongoDBTenancy = mongodb.TenantCollection
+	default:
+		return fmt.Errorf("invalid MONGODB_TENANCY %q, use database or collection", v)
+	}
 
-	creds := strings.Split(os.Getenv("CREDENTIALS"), ",")
-	if len(creds) > 0 && creds[0] != "" {
-		for i, cred := range creds {
-			cred = strings.TrimSpace(cred)
-			if cred == "" {
+	for _, w := range strings.Split(os.Getenv("FILTER_BANNED_WORDS"), ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			cfg.BannedWords = append(cfg.BannedWords, w)
+		}
+	}
+	if v := os.Getenv("FILTER_MAX_LINKS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid FILTER_MAX_LINKS %q, it must be a positive number", v)
+		}
+		cfg.MaxLinks = n
+	}
+	if v := os.Getenv("FILTER_MAX_REPEATED_CHARS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid FILTER_MAX_REPEATED_CHARS %q, it must be a positive number", v)
+		}
+		cfg.MaxRepeatedChars = n
+	}
+	cfg.SpamCSV = os.Getenv("FILTER_SPAM_CSV")
+
+	cfg.RateLimitBurst = 10
+	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid RATE_LIMIT_BURST %q, it must be a positive number", v)
+		}
+		cfg.RateLimitBurst = n
+	}
+	cfg.RateLimitInterval = time.Minute
+	if v := os.Getenv("RATE_LIMIT_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid RATE_LIMIT_INTERVAL %q, it must be a positive duration", v)
+		}
+		cfg.RateLimitInterval = interval
+	}
+	switch cfg.RateLimitKey = os.Getenv("RATE_LIMIT_KEY"); cfg.RateLimitKey {
+	case "":
+		cfg.RateLimitKey = "ip"
+	case "ip", "email":
+	default:
+		return fmt.Errorf("invalid RATE_LIMIT_KEY %q, use ip or email", cfg.RateLimitKey)
+	}
+
+	cfg.DuplicateWindow = 10 * time.Minute
+	if v := os.Getenv("DUPLICATE_WINDOW"); v != "" {
+		window, err := time.ParseDuration(v)
+		if err != nil || window < 0 {
+			return fmt.Errorf("invalid DUPLICATE_WINDOW %q, it must be a positive duration", v)
+		}
+		cfg.DuplicateWindow = window
+	}
+	switch v := os.Getenv("DUPLICATE_POLICY"); v {
+	case "", "reject":
+		cfg.DuplicatePolicy = messageboard.DuplicateReject
+	case "merge":
+		cfg.DuplicatePolicy = messageboard.DuplicateMerge
+	default:
+		return fmt.Errorf("invalid DUPLICATE_POLICY %q, use reject or merge", v)
+	}
+
+	cfg.IdempotencyTTL = mbhttp.DefaultIdempotencyTTL
+	if v := os.Getenv("IDEMPOTENCY_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid IDEMPOTENCY_TTL %q, it must be a positive duration", v)
+		}
+		cfg.IdempotencyTTL = ttl
+	}
+
+	if v := os.Getenv("CHALLENGE_DIFFICULTY"); v != "" {
+		difficulty, err := strconv.Atoi(v)
+		if err != nil || difficulty < 0 || difficulty > mbhttp.MaxChallengeDifficulty {
+			return fmt.Errorf("invalid CHALLENGE_DIFFICULTY %q, it must be a number from 0 to %d", v, mbhttp.MaxChallengeDifficulty)
+		}
+		cfg.ChallengeDifficulty = difficulty
+	}
+	cfg.ChallengeSecret = []byte(os.Getenv("CHALLENGE_SECRET"))
+	if cfg.ChallengeDifficulty > 0 && len(cfg.ChallengeSecret) == 0 {
+		// Challenges issued by an instance are not valid in the others, nor
+		// after restarting it.
+		log.Println("CHALLENGE_SECRET is not set, using a random one")
+		cfg.ChallengeSecret = make([]byte, 32)
+		_, err := rand.Read(cfg.ChallengeSecret)
+		if err != nil {
+			return fmt.Errorf("unable to generate CHALLENGE_SECRET: %v", err)
+		}
+	}
+	cfg.ChallengeTTL = mbhttp.DefaultChallengeTTL
+	if v := os.Getenv("CHALLENGE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid CHALLENGE_TTL %q, it must be a positive duration", v)
+		}
+		cfg.ChallengeTTL = ttl
+	}
+	return nil
+}
+
+// loadUsers maps the envvar name, with the format user1:value1,user2:value2, to
+// a map of users and their values.
+func loadUsers(name string) map[string]string {
+	users := make(map[string]string)
+
+	pairs := strings.Split(os.Getenv(name), ",")
+	if len(pairs) > 0 && pairs[0] != "" {
+		for i, pair := range pairs {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
 				continue
 			}
 
-			usrPasswd := strings.SplitN(cred, ":", 2)
+			usrValue := strings.SplitN(pair, ":", 2)
 
-			var user, passwd string
-			if len(usrPasswd) == 2 {
-				user = strings.TrimSpace(usrPasswd[0])
-				passwd = strings.TrimSpace(usrPasswd[1])
+			var user, value string
+			if len(usrValue) == 2 {
+				user = strings.TrimSpace(usrValue[0])
+				value = strings.TrimSpace(usrValue[1])
 			}
-			if user == "" || passwd == "" {
-				log.Printf("ignoring CREDENTIALS of position %d: %q", i, usrPasswd)
+			if user == "" || value == "" {
+				log.Printf("ignoring %s of position %d: %q", name, i, usrValue)
 				continue
 			}
-			cfg.Credentials[usrPasswd[0]] = usrPasswd[1]
+			users[usrValue[0]] = usrValue[1]
 		}
 	}
-
-	cfg.MongoDBURL = os.Getenv("MONGODB_URL")
-	cfg.MongoDBInitialCSV = os.Getenv("MONGODB_INITIAL_CSV")
-	return nil
+	return users
 }
@@ -0,0 +1,34 @@
+package messageboard
+
+import "context"
+
+type contextKey string
+
+var userCtxKey = contextKey("user")
+
+// ContextWithUser returns a copy of ctx carrying the authenticated user.
+func ContextWithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userCtxKey, user)
+}
+
+// UserFromContext returns the authenticated user stored in ctx, if any.
+func UserFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userCtxKey).(string)
+	return user, ok
+}
+
+var tenantCtxKey = contextKey("tenant")
+
+// ContextWithTenant returns a copy of ctx carrying the tenant, which defines
+// whose data the storages are able to reach.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey, tenant)
+}
+
+// TenantFromContext returns the tenant stored in ctx, or DefaultTenant if there is none.
+func TenantFromContext(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantCtxKey).(string); ok && tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
@@ -0,0 +1,54 @@
+package messageboard
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ReadCSV reads messages from r, calling fn for each one of them.
+//
+// The csv is expected to have a header and 5 fields in the following order:
+// id, name, email, text and creation_time (RFC3339).
+func ReadCSV(r io.Reader, fn func(*Message) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 5
+
+	var i int
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		i++
+		if i == 1 {
+			// It's the header, we can ignore it
+			continue
+		}
+
+		creationTime, err := time.Parse(time.RFC3339, record[4])
+		if err != nil {
+			return fmt.Errorf("invalid time on line %d: %v", i, err)
+		}
+
+		msg := &Message{
+			ID:           record[0],
+			BoardID:      DefaultBoardID,
+			Name:         record[1],
+			Email:        record[2],
+			Text:         record[3],
+			CreationTime: creationTime,
+			Version:      1,
+		}
+		err = fn(msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package messageboard
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Cursor points to a position inside of a list of messages, it's built from the
+// sorted fields and id of the message at the border of a page, this way pages
+// don't shift when messages are added or removed.
+type Cursor struct {
+	// Sort is the sort used to build the cursor, it can only be used with the same sort.
+	Sort string `json:"s"`
+	ID   string `json:"id"`
+	// Values of the fields in Sort.
+	CreationTime time.Time `json:"t"`
+	Name         string    `json:"n,omitempty"`
+	Email        string    `json:"e,omitempty"`
+	// Prev is set when the cursor points to the messages before the position,
+	// instead of the ones after it.
+	Prev bool `json:"p,omitempty"`
+}
+
+// NewCursor returns a cursor pointing to msg in a list sorted by srt.
+func NewCursor(msg *Message, srt Sort, prev bool) *Cursor {
+	c := &Cursor{
+		Sort: srt.String(),
+		ID:   msg.ID,
+		Prev: prev,
+	}
+	for _, f := range srt {
+		switch f.Field {
+		case "creation_time":
+			c.CreationTime = msg.CreationTime
+		case "name":
+			c.Name = msg.Name
+		case "email":
+			c.Email = msg.Email
+		}
+	}
+	return c
+}
+
+// ParseCursor parses a cursor returned by Cursor.String.
+func ParseCursor(s string) (*Cursor, error) {
+	invalidCursor := NewValidationError("invalid_cursor", "cursor is invalid")
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalidCursor
+	}
+	var c *Cursor
+	err = json.Unmarshal(b, &c)
+	if err != nil || c == nil || c.ID == "" {
+		return nil, invalidCursor
+	}
+	if _, err := ParseSort(c.Sort); err != nil {
+		return nil, invalidCursor
+	}
+	return c, nil
+}
+
+// String returns the opaque representation of the cursor.
+func (c *Cursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Value returns the value of field at the cursor position.
+func (c *Cursor) Value(field string) interface{} {
+	switch field {
+	case "creation_time":
+		return c.CreationTime
+	case "name":
+		return c.Name
+	case "email":
+		return c.Email
+	}
+	return c.ID
+}
+
+// message returns a message with the values of the cursor position.
+func (c *Cursor) message() *Message {
+	return &Message{
+		ID:           c.ID,
+		CreationTime: c.CreationTime,
+		Name:         c.Name,
+		Email:        c.Email,
+	}
+}
+
+// IsAfter returns true if msg comes after the cursor position in a list sorted by srt.
+func (c *Cursor) IsAfter(msg *Message, srt Sort) bool {
+	return srt.Compare(msg, c.message()) > 0
+}
+
+// IsBefore returns true if msg comes before the cursor position in a list sorted by srt.
+func (c *Cursor) IsBefore(msg *Message, srt Sort) bool {
+	return srt.Compare(msg, c.message()) < 0
+}
@@ -0,0 +1,71 @@
+package messageboard
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// DuplicatePolicy is what happens when a message being created is a duplicate
+// of a recent one.
+type DuplicatePolicy int
+
+const (
+	// DuplicateReject fails the creation with the error code duplicate.
+	DuplicateReject DuplicatePolicy = iota
+	// DuplicateMerge returns the existing message instead of creating a new one,
+	// with Merged set.
+	DuplicateMerge
+)
+
+// ContentHash returns the hash identifying the content of msg, messages with the
+// same text and email, ignoring case and spaces, have the same hash.
+func ContentHash(msg *Message) string {
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	}
+
+	h := sha256.New()
+	h.Write([]byte(normalize(msg.Email)))
+	// Separator avoiding different emails and texts to have the same hash.
+	h.Write([]byte{0})
+	h.Write([]byte(normalize(msg.Text)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewDuplicateError returns an error caused by a message which was already
+// created, id is the existing message.
+func NewDuplicateError(id string) error {
+	return &Error{
+		Category: CategoryConflict,
+		Code:     "duplicate",
+		Message:  "message was already created",
+		ID:       id,
+	}
+}
+
+// WithDuplicateDetection detects messages created again within window, e.g.
+// forms submitted twice, policy says what happens with them. By default
+// duplicates are not detected.
+func WithDuplicateDetection(window time.Duration, policy DuplicatePolicy) ServiceOption {
+	return func(s *service) {
+		s.duplicateWindow = window
+		s.duplicatePolicy = policy
+	}
+}
+
+// findDuplicate returns the message created within the duplicate window with
+// the same content of msg, or nil if there is none.
+func (s *service) findDuplicate(ctx context.Context, msg *Message) (*Message, error) {
+	if s.duplicateWindow <= 0 {
+		return nil, nil
+	}
+	since := time.Now().UTC().Add(-s.duplicateWindow)
+	dup, err := s.storage.FindDuplicate(ctx, msg, since)
+	if ErrorCategoryOf(err) == CategoryNotFound {
+		return nil, nil
+	}
+	return dup, err
+}
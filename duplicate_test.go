@@ -0,0 +1,34 @@
+package messageboard_test
+
+import (
+	"testing"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentHash(t *testing.T) {
+	hash := messageboard.ContentHash(&messageboard.Message{
+		Name:  "Guilherme",
+		Email: "xguiga@gmail.com",
+		Text:  "My text goes here",
+	})
+	assert.Len(t, hash, 64)
+
+	// Name, case and spaces don't change the hash.
+	assert.Equal(t, hash, messageboard.ContentHash(&messageboard.Message{
+		Name:  "Other",
+		Email: " XGuiga@gmail.com",
+		Text:  "my  text\ngoes HERE ",
+	}))
+
+	assert.NotEqual(t, hash, messageboard.ContentHash(&messageboard.Message{
+		Email: "other@gmail.com",
+		Text:  "My text goes here",
+	}))
+	assert.NotEqual(t, hash, messageboard.ContentHash(&messageboard.Message{
+		Email: "xguiga@gmail.com",
+		Text:  "My other text",
+	}))
+}
@@ -1,14 +1,40 @@
 package messageboard
 
 import (
+	"errors"
 	"fmt"
 )
 
+// ErrorCategory classifies an Error, so callers can react to a whole class of
+// errors (e.g. map them to http status codes) without knowing every code.
+type ErrorCategory string
+
+const (
+	CategoryInternal     ErrorCategory = "internal"
+	CategoryValidation   ErrorCategory = "validation"
+	CategoryConflict     ErrorCategory = "conflict"
+	CategoryPrecondition ErrorCategory = "precondition"
+	CategoryNotFound     ErrorCategory = "not_found"
+	CategoryUnauthorized ErrorCategory = "unauthorized"
+	CategoryForbidden    ErrorCategory = "forbidden"
+	CategoryRateLimited  ErrorCategory = "rate_limited"
+	// CategoryUnsupported is used when the format of the input is not supported.
+	CategoryUnsupported ErrorCategory = "unsupported"
+)
+
 type Error struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Category ErrorCategory `json:"-"`
+	Code     string        `json:"code"`
+	Message  string        `json:"message"`
+	// Fields has the errors of each invalid field, if any.
+	Fields []*FieldError `json:"fields,omitempty"`
+	// ID is the resource which caused the error, if any, e.g. the existing
+	// message of a duplicate.
+	ID string `json:"id,omitempty"`
 }
 
+// NewError returns an Error without category, use one of the New*Error
+// functions to categorize it.
 func NewError(code, msg string) error {
 	return &Error{
 		Code:    code,
@@ -16,6 +42,93 @@ func NewError(code, msg string) error {
 	}
 }
 
+func newCategoryError(cat ErrorCategory, code, msg string) error {
+	return &Error{
+		Category: cat,
+		Code:     code,
+		Message:  msg,
+	}
+}
+
+// NewValidationError returns an error caused by an invalid input.
+func NewValidationError(code, msg string) error {
+	return newCategoryError(CategoryValidation, code, msg)
+}
+
+// NewConflictError returns an error caused by a conflict with the current state of a resource.
+func NewConflictError(code, msg string) error {
+	return newCategoryError(CategoryConflict, code, msg)
+}
+
+// NewPreconditionError returns an error caused by a precondition of the request
+// that doesn't hold, e.g. the resource changed since it was read.
+func NewPreconditionError(code, msg string) error {
+	return newCategoryError(CategoryPrecondition, code, msg)
+}
+
+// NewNotFoundError returns an error caused by a resource that doesn't exist.
+func NewNotFoundError(code, msg string) error {
+	return newCategoryError(CategoryNotFound, code, msg)
+}
+
+// NewUnauthorizedError returns an error caused by a missing or invalid authentication.
+func NewUnauthorizedError(code, msg string) error {
+	return newCategoryError(CategoryUnauthorized, code, msg)
+}
+
+// NewForbiddenError returns an error caused by an authenticated user without permission.
+func NewForbiddenError(code, msg string) error {
+	return newCategoryError(CategoryForbidden, code, msg)
+}
+
+// NewRateLimitedError returns an error caused by too many requests.
+func NewRateLimitedError(code, msg string) error {
+	return newCategoryError(CategoryRateLimited, code, msg)
+}
+
+// NewUnsupportedError returns an error caused by an input in a format that is not supported.
+func NewUnsupportedError(code, msg string) error {
+	return newCategoryError(CategoryUnsupported, code, msg)
+}
+
+// NewInternalError returns an unexpected error.
+func NewInternalError(code, msg string) error {
+	return newCategoryError(CategoryInternal, code, msg)
+}
+
+// FieldError describes why a specific field is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FieldErrors accumulates errors of multiple fields, so all of them can be
+// reported at once.
+type FieldErrors []*FieldError
+
+// Add adds an error to the given field.
+func (errs *FieldErrors) Add(field, code, msg string) {
+	*errs = append(*errs, &FieldError{
+		Field:   field,
+		Code:    code,
+		Message: msg,
+	})
+}
+
+// Err returns a validation error containing all field errors, or nil if there is none.
+func (errs FieldErrors) Err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &Error{
+		Category: CategoryValidation,
+		Code:     "invalid_fields",
+		Message:  "one or more fields are invalid",
+		Fields:   errs,
+	}
+}
+
 func (e Error) Error() string {
 	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
 }
@@ -24,3 +137,25 @@ func (e Error) Is(target error) bool {
 	_, ok := target.(*Error)
 	return ok
 }
+
+// ErrorCategoryOf returns the category of err, errors that are not an *Error
+// are considered internal.
+func ErrorCategoryOf(err error) ErrorCategory {
+	var mberr *Error
+	if !errors.As(err, &mberr) {
+		return CategoryInternal
+	}
+	if mberr.Category != "" {
+		return mberr.Category
+	}
+
+	// Errors created without category, keep the behaviour of the codes
+	// which have been used before categories exist.
+	switch mberr.Code {
+	case "not_found":
+		return CategoryNotFound
+	case "unauthorized":
+		return CategoryUnauthorized
+	}
+	return CategoryInternal
+}
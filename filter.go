@@ -0,0 +1,89 @@
+package messageboard
+
+import (
+	"context"
+)
+
+// FilterAction is what a ContentFilter decided about a message.
+type FilterAction string
+
+const (
+	// FilterAccept lets the message through.
+	FilterAccept FilterAction = "accept"
+	// FilterReject refuses the message, it's not stored.
+	FilterReject FilterAction = "reject"
+	// FilterFlag stores the message with StatusFlagged, waiting for a moderator.
+	FilterFlag FilterAction = "flag"
+)
+
+// FilterResult is the decision of a ContentFilter and why it was taken.
+type FilterResult struct {
+	Action FilterAction
+	Reason string
+}
+
+// Accept returns a result accepting the message.
+func Accept() FilterResult {
+	return FilterResult{Action: FilterAccept}
+}
+
+// Reject returns a result rejecting the message because of reason.
+func Reject(reason string) FilterResult {
+	return FilterResult{Action: FilterReject, Reason: reason}
+}
+
+// Flag returns a result flagging the message because of reason.
+func Flag(reason string) FilterResult {
+	return FilterResult{Action: FilterFlag, Reason: reason}
+}
+
+// ContentFilter checks the content of the messages before they are created or
+// updated, e.g. to keep spam out of the board.
+type ContentFilter interface {
+	Filter(ctx context.Context, msg *Message) (FilterResult, error)
+}
+
+// ContentFilterFunc allows the use of ordinary functions as ContentFilter.
+type ContentFilterFunc func(ctx context.Context, msg *Message) (FilterResult, error)
+
+// Filter calls fn(ctx, msg).
+func (fn ContentFilterFunc) Filter(ctx context.Context, msg *Message) (FilterResult, error) {
+	return fn(ctx, msg)
+}
+
+// FilterChain runs its filters in order. The first one rejecting the message
+// stops the chain, otherwise the first flag is returned, if any.
+type FilterChain []ContentFilter
+
+// Filter implements ContentFilter.
+func (c FilterChain) Filter(ctx context.Context, msg *Message) (FilterResult, error) {
+	res := Accept()
+	for _, f := range c {
+		r, err := f.Filter(ctx, msg)
+		if err != nil {
+			return FilterResult{}, err
+		}
+		switch r.Action {
+		case FilterReject:
+			return r, nil
+		case FilterFlag:
+			if res.Action == FilterAccept {
+				res = r
+			}
+		}
+	}
+	return res, nil
+}
+
+// filterContent runs the filters of the service on msg. Rejected messages
+// return an error, flagged ones have their status changed.
+func (s *service) filterContent(ctx context.Context, msg *Message) (FilterResult, error) {
+	res, err := s.filters.Filter(ctx, msg)
+	if err != nil {
+		return FilterResult{}, err
+	}
+	if res.Action == FilterReject {
+		return res, NewValidationError("content_rejected", res.Reason)
+	}
+	return res, nil
+}
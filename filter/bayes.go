@@ -0,0 +1,153 @@
+package filter
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/guilherme-santos/messageboard"
+)
+
+// DefaultSpamThreshold is the default of Bayes.Threshold.
+const DefaultSpamThreshold = 0.9
+
+const (
+	ham = iota
+	spam
+)
+
+// Bayes is a naive Bayes classifier telling spam apart from legitimate messages.
+// It only filters messages after being trained with both of them.
+type Bayes struct {
+	// Threshold is the probability of being spam from which Action is taken.
+	Threshold float64
+	// Action is taken for spam, by default the message is flagged.
+	Action messageboard.FilterAction
+
+	mu sync.RWMutex
+	// docs is how many messages of each class were trained.
+	docs [2]int
+	// counts is how many times each word was seen in each class.
+	counts [2]map[string]int
+	// total is the sum of counts of each class.
+	total [2]int
+	vocab map[string]bool
+}
+
+// NewBayes returns an untrained classifier.
+func NewBayes() *Bayes {
+	return &Bayes{
+		Threshold: DefaultSpamThreshold,
+		Action:    messageboard.FilterFlag,
+		counts:    [2]map[string]int{make(map[string]int), make(map[string]int)},
+		vocab:     make(map[string]bool),
+	}
+}
+
+// Train teaches the classifier that text is spam, or not.
+func (b *Bayes) Train(text string, isSpam bool) {
+	class := ham
+	if isSpam {
+		class = spam
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.docs[class]++
+	for _, w := range messageboard.Terms(text) {
+		b.counts[class][w]++
+		b.total[class]++
+		b.vocab[w] = true
+	}
+}
+
+// Trained returns true if the classifier has seen both spam and legitimate messages.
+func (b *Bayes) Trained() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.docs[ham] > 0 && b.docs[spam] > 0
+}
+
+// SpamProbability returns the probability, from 0 to 1, of text being spam.
+func (b *Bayes) SpamProbability(text string) float64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	docs := float64(b.docs[ham] + b.docs[spam])
+	vocab := float64(len(b.vocab))
+
+	// Logarithms avoid underflows multiplying many small probabilities, and
+	// the laplace smoothing avoids zeros for words not seen in a class.
+	var score [2]float64
+	for class := range score {
+		score[class] = math.Log(float64(b.docs[class]) / docs)
+		for _, w := range messageboard.Terms(text) {
+			score[class] += math.Log(float64(b.counts[class][w]+1) / (float64(b.total[class]) + vocab))
+		}
+	}
+	return 1 / (1 + math.Exp(score[ham]-score[spam]))
+}
+
+// Filter implements messageboard.ContentFilter.
+func (b *Bayes) Filter(_ context.Context, msg *messageboard.Message) (messageboard.FilterResult, error) {
+	if !b.Trained() {
+		return messageboard.Accept(), nil
+	}
+	p := b.SpamProbability(content(msg))
+	if p >= b.Threshold {
+		return result(b.Action, fmt.Sprintf("message looks like spam (%.0f%%)", p*100)), nil
+	}
+	return messageboard.Accept(), nil
+}
+
+// LoadCSV trains the classifier with the messages in the file name, see TrainCSV.
+func (b *Bayes) LoadCSV(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return b.TrainCSV(f)
+}
+
+// TrainCSV trains the classifier with the messages read from r.
+//
+// The csv is expected to have a header and 2 fields in the following order:
+// label (spam or ham) and text.
+func (b *Bayes) TrainCSV(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+
+	var i int
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		i++
+		if i == 1 {
+			// It's the header, we can ignore it
+			continue
+		}
+
+		switch label := strings.ToLower(strings.TrimSpace(record[0])); label {
+		case "spam":
+			b.Train(record[1], true)
+		case "ham":
+			b.Train(record[1], false)
+		default:
+			return fmt.Errorf("invalid label %q on line %d, use spam or ham", record[0], i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package filter_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/guilherme-santos/messageboard"
+	"github.com/guilherme-santos/messageboard/filter"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const trainingCSV = `label,text
+spam,"Win a free prize now, click here"
+spam,Cheap pills with free shipping
+spam,"Click here to claim your free money"
+ham,Does anyone know how to configure the proxy?
+ham,"The meeting was moved to tomorrow, see you there"
+HAM,I liked the new version of the board
+`
+
+func TestBayes(t *testing.T) {
+	b := filter.NewBayes()
+	err := b.TrainCSV(strings.NewReader(trainingCSV))
+	require.NoError(t, err)
+	assert.True(t, b.Trained())
+
+	assert.Greater(t, b.SpamProbability("click here for a free prize"), 0.9)
+	assert.Less(t, b.SpamProbability("see you in the meeting tomorrow"), 0.1)
+
+	res, err := b.Filter(context.Background(), &messageboard.Message{Name: "Winner", Text: "Free money, click here!"})
+	require.NoError(t, err)
+	assert.Equal(t, messageboard.FilterFlag, res.Action)
+	assert.Contains(t, res.Reason, "message looks like spam")
+
+	res, err = b.Filter(context.Background(), &messageboard.Message{Name: "Guilherme", Text: "How do I configure the new version?"})
+	require.NoError(t, err)
+	assert.Equal(t, messageboard.Accept(), res)
+}
+
+func TestBayes_Untrained(t *testing.T) {
+	b := filter.NewBayes()
+	b.Train("free money", true)
+	assert.False(t, b.Trained())
+
+	res, err := b.Filter(context.Background(), &messageboard.Message{Name: "Winner", Text: "free money"})
+	require.NoError(t, err)
+	assert.Equal(t, messageboard.Accept(), res)
+}
+
+func TestBayes_TrainCSVInvalid(t *testing.T) {
+	err := filter.NewBayes().TrainCSV(strings.NewReader("label,text\nspammy,free money\n"))
+	assert.EqualError(t, err, `invalid label "spammy" on line 2, use spam or ham`)
+
+	err = filter.NewBayes().TrainCSV(strings.NewReader("label,text\nspam\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+// Package filter has the built-in implementations of messageboard.ContentFilter.
+package filter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/guilherme-santos/messageboard"
+)
+
+// content returns the text written by the author of msg.
+func content(msg *messageboard.Message) string {
+	return msg.Name + "\n" + msg.Text
+}
+
+// words splits s in lowercase words, anything that is not a letter or a digit is a separator.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// result returns the result of action because of reason.
+func result(action messageboard.FilterAction, reason string) messageboard.FilterResult {
+	return messageboard.FilterResult{Action: action, Reason: reason}
+}
+
+// BannedWords filters messages with words that are not allowed in the board.
+type BannedWords struct {
+	// Action is taken when a banned word is found, by default the message is rejected.
+	Action messageboard.FilterAction
+	words  map[string]bool
+}
+
+// NewBannedWords returns a filter for words, which are case insensitive.
+func NewBannedWords(words ...string) *BannedWords {
+	f := &BannedWords{
+		Action: messageboard.FilterReject,
+		words:  make(map[string]bool),
+	}
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w != "" {
+			f.words[w] = true
+		}
+	}
+	return f
+}
+
+// Filter implements messageboard.ContentFilter.
+func (f *BannedWords) Filter(_ context.Context, msg *messageboard.Message) (messageboard.FilterResult, error) {
+	for _, w := range words(content(msg)) {
+		if f.words[w] {
+			return result(f.Action, fmt.Sprintf("message has the banned word %q", w)), nil
+		}
+	}
+	return messageboard.Accept(), nil
+}
+
+var linkRegexp = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s]+`)
+
+// LinkLimit filters messages with too many links, which is common in spam.
+type LinkLimit struct {
+	// Max is the number of links allowed in a message.
+	Max int
+	// Action is taken when there are more than Max links, by default the message is flagged.
+	Action messageboard.FilterAction
+}
+
+// NewLinkLimit returns a filter allowing up to max links per message.
+func NewLinkLimit(max int) *LinkLimit {
+	return &LinkLimit{
+		Max:    max,
+		Action: messageboard.FilterFlag,
+	}
+}
+
+// Filter implements messageboard.ContentFilter.
+func (f *LinkLimit) Filter(_ context.Context, msg *messageboard.Message) (messageboard.FilterResult, error) {
+	n := len(linkRegexp.FindAllString(content(msg), -1))
+	if n > f.Max {
+		return result(f.Action, fmt.Sprintf("message has %d links, the maximum is %d", n, f.Max)), nil
+	}
+	return messageboard.Accept(), nil
+}
+
+// RepeatedChars filters messages repeating the same character over and over,
+// e.g. "heeeeeeeey!!!!!!!!".
+type RepeatedChars struct {
+	// Max is how many times in a row a character can appear.
+	Max int
+	// Action is taken when a character is repeated more than Max times, by default the message is flagged.
+	Action messageboard.FilterAction
+}
+
+// NewRepeatedChars returns a filter allowing a character to be repeated up to max times in a row.
+func NewRepeatedChars(max int) *RepeatedChars {
+	return &RepeatedChars{
+		Max:    max,
+		Action: messageboard.FilterFlag,
+	}
+}
+
+// Filter implements messageboard.ContentFilter.
+func (f *RepeatedChars) Filter(_ context.Context, msg *messageboard.Message) (messageboard.FilterResult, error) {
+	var (
+		last rune
+		n    int
+	)
+	for _, r := range content(msg) {
+		if r != last {
+			last, n = r, 0
+		}
+		n++
+		// Spaces are used to align text, they are not checked.
+		if n > f.Max && !unicode.IsSpace(r) {
+			return result(f.Action, fmt.Sprintf("message repeats %q more than %d times", r, f.Max)), nil
+		}
+	}
+	return messageboard.Accept(), nil
+}
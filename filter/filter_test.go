@@ -0,0 +1,86 @@
+package filter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guilherme-santos/messageboard"
+	"github.com/guilherme-santos/messageboard/filter"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter messageboard.ContentFilter
+		msg    *messageboard.Message
+		result messageboard.FilterResult
+	}{
+		{
+			name:   "banned word",
+			filter: filter.NewBannedWords("Casino", " viagra "),
+			msg:    &messageboard.Message{Name: "Guilherme", Text: "Best CASINO in town!"},
+			result: messageboard.Reject(`message has the banned word "casino"`),
+		},
+		{
+			name:   "banned word in name",
+			filter: filter.NewBannedWords("casino"),
+			msg:    &messageboard.Message{Name: "Casino Royale", Text: "Hello"},
+			result: messageboard.Reject(`message has the banned word "casino"`),
+		},
+		{
+			name:   "banned word inside another word",
+			filter: filter.NewBannedWords("ass"),
+			msg:    &messageboard.Message{Name: "Guilherme", Text: "I'll pass by tomorrow"},
+			result: messageboard.Accept(),
+		},
+		{
+			name:   "links",
+			filter: filter.NewLinkLimit(1),
+			msg:    &messageboard.Message{Name: "Guilherme", Text: "See https://example.com and www.example.org"},
+			result: messageboard.Flag("message has 2 links, the maximum is 1"),
+		},
+		{
+			name:   "links below the limit",
+			filter: filter.NewLinkLimit(1),
+			msg:    &messageboard.Message{Name: "Guilherme", Text: "See HTTP://example.com"},
+			result: messageboard.Accept(),
+		},
+		{
+			name:   "no links allowed",
+			filter: filter.NewLinkLimit(0),
+			msg:    &messageboard.Message{Name: "Guilherme", Text: "See http://example.com"},
+			result: messageboard.Flag("message has 1 links, the maximum is 0"),
+		},
+		{
+			name:   "repeated chars",
+			filter: filter.NewRepeatedChars(3),
+			msg:    &messageboard.Message{Name: "Guilherme", Text: "Heeey!!!!"},
+			result: messageboard.Flag(`message repeats '!' more than 3 times`),
+		},
+		{
+			name:   "repeated spaces",
+			filter: filter.NewRepeatedChars(3),
+			msg:    &messageboard.Message{Name: "Guilherme", Text: "Heeey     you"},
+			result: messageboard.Accept(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.filter.Filter(context.Background(), tt.msg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.result, res)
+		})
+	}
+}
+
+func TestFilters_Action(t *testing.T) {
+	f := filter.NewBannedWords("casino")
+	f.Action = messageboard.FilterFlag
+
+	res, err := f.Filter(context.Background(), &messageboard.Message{Name: "Guilherme", Text: "casino"})
+	require.NoError(t, err)
+	assert.Equal(t, messageboard.FilterFlag, res.Action)
+}
@@ -13,23 +13,25 @@ func BasicAuth(realm string, creds map[string]string) func(next http.Handler) ht
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, pass, ok := r.BasicAuth()
 			if !ok {
-				basicAuthFailed(w, realm)
+				basicAuthFailed(w, r, realm)
 				return
 			}
 
 			credPass, credUserOk := creds[user]
 			if !credUserOk || pass != credPass {
-				basicAuthFailed(w, realm)
+				basicAuthFailed(w, r, realm)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			// Save the user in the context, so the service knows who is doing the request.
+			ctx := messageboard.ContextWithUser(r.Context(), user)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-func basicAuthFailed(w http.ResponseWriter, realm string) {
+func basicAuthFailed(w http.ResponseWriter, r *http.Request, realm string) {
 	w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, realm))
-	err := messageboard.NewError("unauthorized", "user is not authorized to access this resource")
-	responseError(w, err)
+	err := messageboard.NewUnauthorizedError("unauthorized", "user is not authorized to access this resource")
+	responseError(w, r, err)
 }
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/go-chi/chi"
+)
+
+var boardCtxKey = contextKey("board")
+
+// loadBoard loads the board in the url, responding not found if it doesn't exist.
+func (h *MessageBoardHandler) loadBoard(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		b, err := h.svc.GetBoard(ctx, chi.URLParamFromCtx(ctx, "board"))
+		if err != nil {
+			responseError(w, r, err)
+			return
+		}
+
+		ctx = context.WithValue(ctx, boardCtxKey, b)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// boardAuth requires authentication to post messages to private boards.
+func (h *MessageBoardHandler) boardAuth(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		b, ok := ctx.Value(boardCtxKey).(*messageboard.Board)
+		if !ok {
+			var err error
+			b, err = h.svc.GetBoard(ctx, messageboard.DefaultBoardID)
+			if err != nil {
+				responseError(w, r, err)
+				return
+			}
+		}
+
+		if b.Visibility == messageboard.VisibilityPrivate {
+			h.auth(next).ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// boardID returns the id of the board loaded by loadBoard, or the default board
+// for routes without board.
+func boardID(ctx context.Context) string {
+	if b, ok := ctx.Value(boardCtxKey).(*messageboard.Board); ok {
+		return b.ID
+	}
+	return messageboard.DefaultBoardID
+}
+
+func (h *MessageBoardHandler) listBoards(w http.ResponseWriter, req *http.Request) {
+	list, err := h.svc.ListBoards(req.Context())
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, list)
+}
+
+func (h *MessageBoardHandler) createBoard(w http.ResponseWriter, req *http.Request) {
+	var reqBoard *messageboard.Board
+	err := json.NewDecoder(req.Body).Decode(&reqBoard)
+	if err != nil {
+		responseError(w, req, messageboard.NewValidationError("invalid_json", err.Error()))
+		return
+	}
+	if reqBoard == nil {
+		responseError(w, req, messageboard.NewValidationError("invalid_json", "body must be a board"))
+		return
+	}
+
+	b, err := h.svc.CreateBoard(req.Context(), reqBoard)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusCreated, b)
+}
+
+func (h *MessageBoardHandler) getBoard(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	b, err := h.svc.GetBoard(ctx, chi.URLParamFromCtx(ctx, "board"))
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, b)
+}
+
+func (h *MessageBoardHandler) updateBoard(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	var reqBoard *messageboard.Board
+	err := json.NewDecoder(req.Body).Decode(&reqBoard)
+	if err != nil {
+		responseError(w, req, messageboard.NewValidationError("invalid_json", err.Error()))
+		return
+	}
+	if reqBoard == nil {
+		responseError(w, req, messageboard.NewValidationError("invalid_json", "body must be a board"))
+		return
+	}
+	reqBoard.ID = chi.URLParamFromCtx(ctx, "board")
+
+	b, err := h.svc.UpdateBoard(ctx, reqBoard)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, b)
+}
+
+func (h *MessageBoardHandler) deleteBoard(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	err := h.svc.DeleteBoard(ctx, chi.URLParamFromCtx(ctx, "board"))
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,434 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+	mbhttp "github.com/guilherme-santos/messageboard/http"
+	"github.com/guilherme-santos/messageboard/memory"
+	"github.com/guilherme-santos/messageboard/mock"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoardHandler_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		ListBoards(gomock.Any()).
+		Return(&messageboard.BoardList{
+			Total: 2,
+			Data: []*messageboard.Board{
+				messageboard.DefaultBoard(),
+				{
+					ID:           "golang",
+					Title:        "Golang",
+					Visibility:   messageboard.VisibilityPrivate,
+					CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+				},
+			},
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/boards", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"total": 2,
+		"data": [
+			{
+				"id": "default",
+				"title": "Message Board",
+				"visibility": "public",
+				"creation_time": "0001-01-01T00:00:00Z"
+			},
+			{
+				"id": "golang",
+				"title": "Golang",
+				"visibility": "private",
+				"creation_time": "2020-08-12T15:30:00Z"
+			}
+		]
+	}`, w.Body.String())
+}
+
+func TestBoardHandler_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		CreateBoard(gomock.Any(), &messageboard.Board{
+			ID:    "golang",
+			Title: "Golang",
+		}).
+		Return(&messageboard.Board{
+			ID:           "golang",
+			Title:        "Golang",
+			Visibility:   messageboard.VisibilityPublic,
+			CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/boards", strings.NewReader(`{"id":"golang","title":"Golang"}`))
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{
+		"id": "golang",
+		"title": "Golang",
+		"visibility": "public",
+		"creation_time": "2020-08-12T15:30:00Z"
+	}`, w.Body.String())
+}
+
+func TestBoardHandler_CreateUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/boards", strings.NewReader(`{"id":"golang","title":"Golang"}`))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestBoardHandler_Update(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		UpdateBoard(gomock.Any(), &messageboard.Board{
+			ID:         "golang",
+			Title:      "Golang",
+			Visibility: messageboard.VisibilityPrivate,
+		}).
+		Return(&messageboard.Board{
+			ID:           "golang",
+			Title:        "Golang",
+			Visibility:   messageboard.VisibilityPrivate,
+			CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	// The id in the body is ignored, the board is always the one in the url.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost/v1/boards/golang", strings.NewReader(`{"id":"other","title":"Golang","visibility":"private"}`))
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"id": "golang",
+		"title": "Golang",
+		"visibility": "private",
+		"creation_time": "2020-08-12T15:30:00Z"
+	}`, w.Body.String())
+}
+
+func TestBoardHandler_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		DeleteBoard(gomock.Any(), "golang").
+		Return(nil)
+	svc.EXPECT().
+		DeleteBoard(gomock.Any(), "art").
+		Return(messageboard.NewConflictError("board_not_empty", "board still has messages, including the ones in the trash"))
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "http://localhost/v1/boards/golang", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, "http://localhost/v1/boards/art", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{
+		"code": "board_not_empty",
+		"message": "board still has messages, including the ones in the trash"
+	}`, w.Body.String())
+}
+
+func TestBoardHandler_CreateMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), "golang").
+		Return(&messageboard.Board{ID: "golang", Visibility: messageboard.VisibilityPublic}, nil)
+	svc.EXPECT().
+		Create(gomock.Any(), &messageboard.Message{
+			BoardID: "golang",
+			Name:    "Guilherme",
+			Email:   "xguiga@gmail.com",
+			Text:    "My text goes here",
+		}).
+		Return(&messageboard.Message{
+			ID:           "my-id",
+			BoardID:      "golang",
+			Name:         "Guilherme",
+			Email:        "xguiga@gmail.com",
+			Text:         "My text goes here",
+			CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/boards/golang/messages",
+		strings.NewReader(`{"name":"Guilherme","email":"xguiga@gmail.com","text":"My text goes here"}`))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{
+		"id": "my-id",
+		"board_id": "golang",
+		"name": "Guilherme",
+		"email": "xguiga@gmail.com",
+		"text": "My text goes here",
+		"creation_time": "2020-08-12T15:30:00Z"
+	}`, w.Body.String())
+}
+
+func TestBoardHandler_CreateMessagePrivate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), "golang").
+		Return(&messageboard.Board{ID: "golang", Visibility: messageboard.VisibilityPrivate}, nil).
+		Times(2)
+	svc.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&messageboard.Message{ID: "my-id", BoardID: "golang"}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	body := `{"name":"Guilherme","email":"xguiga@gmail.com","text":"My text goes here"}`
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/boards/golang/messages", strings.NewReader(body))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost/v1/boards/golang/messages", strings.NewReader(body))
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestBoardHandler_BoardNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), "golang").
+		Return(nil, messageboard.NewNotFoundError("not_found", "board was not found"))
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/boards/golang/messages",
+		strings.NewReader(`{"name":"Guilherme","email":"xguiga@gmail.com","text":"My text goes here"}`))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{
+		"code": "not_found",
+		"message": "board was not found"
+	}`, w.Body.String())
+}
+
+func TestBoardHandler_ListMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), "golang").
+		Return(&messageboard.Board{ID: "golang"}, nil)
+	svc.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{
+			BoardID: "golang",
+			PerPage: messageboard.DefaultPerPage,
+			Page:    1,
+		}).
+		Return(&messageboard.MessageList{
+			Total: 0,
+			Data:  []*messageboard.Message{},
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/boards/golang/messages", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"total": 0,
+		"data": []
+	}`, w.Body.String())
+}
+
+func TestBoardHandler_GetMessageOtherBoard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), "golang").
+		Return(&messageboard.Board{ID: "golang"}, nil)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{ID: "my-id", BoardID: "art"}, nil).
+		Times(2)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	for _, url := range []string{
+		"http://localhost/v1/boards/golang/messages/my-id",
+		"http://localhost/v1/messages/my-id",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.SetBasicAuth("test", "testpasswd")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, url)
+		assert.JSONEq(t, `{
+			"code": "not_found",
+			"message": "message was not found"
+		}`, w.Body.String(), url)
+	}
+}
+
+func TestBoardHandler_RestoreMessageOtherBoard(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.NewMessageBoardStorage()
+	svc := messageboard.NewService(storage)
+
+	_, err := svc.CreateBoard(ctx, &messageboard.Board{ID: "golang", Title: "Golang"})
+	require.NoError(t, err)
+	msg := &messageboard.Message{Name: "Guilherme", Email: "xguiga@gmail.com", Text: "My text goes here"}
+	err = storage.Create(ctx, msg)
+	require.NoError(t, err)
+	err = storage.Delete(ctx, msg.ID, "test")
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	do := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, nil)
+		req.SetBasicAuth("test", "testpasswd")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "http://localhost/v1/boards/golang/messages/"+msg.ID+"/restore")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{
+		"code": "not_found",
+		"message": "message was not found in the trash"
+	}`, w.Body.String())
+
+	// The message is still in the trash.
+	w = do(http.MethodGet, "http://localhost/v1/messages/"+msg.ID)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodPost, "http://localhost/v1/messages/"+msg.ID+"/restore")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBoardHandler_NullBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, mock.NewService(ctrl), credentials)
+
+	tests := []struct {
+		method string
+		url    string
+	}{
+		{http.MethodPost, "http://localhost/v1/boards"},
+		{http.MethodPut, "http://localhost/v1/boards/golang"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader("null"))
+		req.SetBasicAuth("test", "testpasswd")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.method)
+		assert.JSONEq(t, `{
+			"code": "invalid_json",
+			"message": "body must be a board"
+		}`, w.Body.String(), tt.method)
+	}
+}
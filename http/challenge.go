@@ -0,0 +1,243 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+)
+
+const (
+	// DefaultChallengeTTL is how long a challenge can be solved, by default.
+	DefaultChallengeTTL = 5 * time.Minute
+	// MaxChallengeDifficulty is the maximum difficulty of a challenge, each
+	// bit doubles the work needed to solve it.
+	MaxChallengeDifficulty = 32
+)
+
+// Challenge is a puzzle which anonymous clients have to solve before posting a
+// message, a solution is a string which the sha256 of "<challenge>:<solution>"
+// starts with Difficulty bits set to zero.
+type Challenge struct {
+	Challenge      string    `json:"challenge"`
+	Algorithm      string    `json:"algorithm"`
+	Difficulty     int       `json:"difficulty"`
+	ExpirationTime time.Time `json:"expiration_time"`
+}
+
+// ReplayCache remembers the challenges already solved, so each of them is used only once.
+type ReplayCache interface {
+	// Add saves key until expiration, it returns false if key is already saved.
+	Add(key string, expiration time.Time) bool
+}
+
+// MemoryReplayCache is an in-process ReplayCache.
+type MemoryReplayCache struct {
+	mu        sync.Mutex
+	keys      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{
+		keys:      make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Add implements ReplayCache.
+func (c *MemoryReplayCache) Add(key string, expiration time.Time) bool {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep(now)
+	if exp, ok := c.keys[key]; ok && exp.After(now) {
+		return false
+	}
+	c.keys[key] = expiration
+	return true
+}
+
+// replaySweepInterval is how often the expired keys are removed.
+const replaySweepInterval = time.Minute
+
+// sweep removes the expired keys from time to time, they can't be replayed
+// anyway since the challenge is rejected before.
+func (c *MemoryReplayCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < replaySweepInterval {
+		return
+	}
+	for k, exp := range c.keys {
+		if !exp.After(now) {
+			delete(c.keys, k)
+		}
+	}
+	c.lastSweep = now
+}
+
+// ProofOfWork issues and verifies challenges signed with HMAC, the challenges
+// don't need to be stored, only the ones solved are kept in the ReplayCache.
+type ProofOfWork struct {
+	secret     []byte
+	difficulty int
+	ttl        time.Duration
+	replays    ReplayCache
+}
+
+// NewProofOfWork returns a ProofOfWork signing the challenges with secret, which
+// must be the same in all instances of the service. The challenges have the
+// given difficulty and expire after ttl.
+func NewProofOfWork(secret []byte, difficulty int, ttl time.Duration, replays ReplayCache) *ProofOfWork {
+	return &ProofOfWork{
+		secret:     secret,
+		difficulty: difficulty,
+		ttl:        ttl,
+		replays:    replays,
+	}
+}
+
+// sign returns the signature of the challenge payload in the tenant, so the
+// challenges of a tenant can't be used in others.
+func (p *ProofOfWork) sign(tenant, payload string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(tenant + "\n" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Issue returns a new challenge for tenant.
+func (p *ProofOfWork) Issue(tenant string) (*Challenge, error) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	exp := time.Now().UTC().Add(p.ttl).Truncate(time.Second)
+	payload := fmt.Sprintf("%s.%d.%d", hex.EncodeToString(nonce), exp.Unix(), p.difficulty)
+	return &Challenge{
+		Challenge:      payload + "." + p.sign(tenant, payload),
+		Algorithm:      "sha256",
+		Difficulty:     p.difficulty,
+		ExpirationTime: exp,
+	}, nil
+}
+
+var (
+	errInvalidChallenge = messageboard.NewForbiddenError("invalid_challenge", "challenge is invalid, get a new one at /v1/challenge")
+	errChallengeExpired = messageboard.NewForbiddenError("challenge_expired", "challenge is expired, get a new one at /v1/challenge")
+)
+
+// Verify returns an error if solution doesn't solve the challenge issued for
+// tenant, or if the challenge was already used.
+func (p *ProofOfWork) Verify(tenant, challenge, solution string) error {
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 {
+		return errInvalidChallenge
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(p.sign(tenant, payload))) {
+		return errInvalidChallenge
+	}
+
+	expUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return errInvalidChallenge
+	}
+	exp := time.Unix(expUnix, 0)
+	if !exp.After(time.Now()) {
+		return errChallengeExpired
+	}
+	// Challenges issued before the difficulty was increased are not accepted.
+	difficulty, err := strconv.Atoi(parts[2])
+	if err != nil || difficulty < p.difficulty {
+		return errInvalidChallenge
+	}
+
+	if !solves(challenge, solution, difficulty) {
+		return messageboard.NewForbiddenError("invalid_solution", "solution doesn't solve the challenge")
+	}
+	if !p.replays.Add(tenant+"/"+parts[0], exp) {
+		return messageboard.NewForbiddenError("challenge_already_used", "challenge was already used, get a new one at /v1/challenge")
+	}
+	return nil
+}
+
+// solves returns true if the sha256 of "<challenge>:<solution>" starts with
+// difficulty bits set to zero.
+func solves(challenge, solution string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(challenge + ":" + solution))
+	for _, b := range sum {
+		if difficulty <= 0 {
+			return true
+		}
+		if difficulty < 8 {
+			return b>>(8-difficulty) == 0
+		}
+		if b != 0 {
+			return false
+		}
+		difficulty -= 8
+	}
+	return difficulty <= 0
+}
+
+// WithProofOfWork requires anonymous clients to solve a challenge of pow before
+// creating messages, the challenges are issued at /v1/challenge. By default no
+// challenge is required.
+func WithProofOfWork(pow *ProofOfWork) HandlerOption {
+	return func(h *MessageBoardHandler) {
+		h.pow = pow
+	}
+}
+
+func (h *MessageBoardHandler) issueChallenge(w http.ResponseWriter, req *http.Request) {
+	c, err := h.pow.Issue(messageboard.TenantFromContext(req.Context()))
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	// Each client must solve its own challenge.
+	w.Header().Set("Cache-Control", "no-store")
+	responseJSON(w, http.StatusOK, c)
+}
+
+// requireChallenge responds forbidden to anonymous clients without the
+// solution of a challenge, in the headers X-Challenge and X-Challenge-Solution.
+func (h *MessageBoardHandler) requireChallenge(next http.Handler) http.Handler {
+	if h.pow == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		// Authenticated users, e.g. posting to private boards, are trusted.
+		if _, ok := messageboard.UserFromContext(ctx); ok {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		challenge := req.Header.Get("X-Challenge")
+		solution := req.Header.Get("X-Challenge-Solution")
+		if challenge == "" || solution == "" {
+			responseError(w, req, messageboard.NewForbiddenError("challenge_required", "headers \"X-Challenge\" and \"X-Challenge-Solution\" are required, get a challenge at /v1/challenge"))
+			return
+		}
+		err := h.pow.Verify(messageboard.TenantFromContext(ctx), challenge, solution)
+		if err != nil {
+			responseError(w, req, err)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,188 @@
+package http_test
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"math/bits"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+	mbhttp "github.com/guilherme-santos/messageboard/http"
+	"github.com/guilherme-santos/messageboard/mock"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProofOfWork(t *testing.T) {
+	pow := mbhttp.NewProofOfWork([]byte("secret"), 8, time.Minute, mbhttp.NewMemoryReplayCache())
+
+	c, err := pow.Issue("acme")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256", c.Algorithm)
+	assert.Equal(t, 8, c.Difficulty)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), c.ExpirationTime, time.Second)
+
+	solution := solve(c.Challenge, c.Difficulty)
+
+	// Look for a string which is not a solution.
+	wrong := "wrong"
+	for zeroBits(c.Challenge, wrong) >= c.Difficulty {
+		wrong += "x"
+	}
+
+	parts := strings.Split(c.Challenge, ".")
+	easier := strings.Join([]string{parts[0], parts[1], "1", parts[3]}, ".")
+
+	expired, err := mbhttp.NewProofOfWork([]byte("secret"), 8, -time.Second, mbhttp.NewMemoryReplayCache()).Issue("acme")
+	require.NoError(t, err)
+
+	other, err := mbhttp.NewProofOfWork([]byte("other-secret"), 8, time.Minute, mbhttp.NewMemoryReplayCache()).Issue("acme")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		tenant    string
+		challenge string
+		solution  string
+		code      string
+	}{
+		{"malformed", "acme", "invalid", solution, "invalid_challenge"},
+		{"other tenant", "globex", c.Challenge, solution, "invalid_challenge"},
+		{"other secret", "acme", other.Challenge, solve(other.Challenge, 8), "invalid_challenge"},
+		{"changed difficulty", "acme", easier, solve(easier, 1), "invalid_challenge"},
+		{"expired", "acme", expired.Challenge, solve(expired.Challenge, 8), "challenge_expired"},
+		{"wrong solution", "acme", c.Challenge, wrong, "invalid_solution"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pow.Verify(tt.tenant, tt.challenge, tt.solution)
+			assertErrorCode(t, tt.code, err)
+		})
+	}
+
+	err = pow.Verify("acme", c.Challenge, solution)
+	assert.NoError(t, err)
+
+	// Each challenge is used only once.
+	err = pow.Verify("acme", c.Challenge, solution)
+	assertErrorCode(t, "challenge_already_used", err)
+}
+
+// solve returns a solution of challenge, it's what the clients are expected to do.
+func solve(challenge string, difficulty int) string {
+	for i := 0; ; i++ {
+		solution := strconv.Itoa(i)
+		if zeroBits(challenge, solution) >= difficulty {
+			return solution
+		}
+	}
+}
+
+// zeroBits returns how many bits the sha256 of "<challenge>:<solution>" starts with set to zero.
+func zeroBits(challenge, solution string) int {
+	var n int
+	for _, b := range sha256.Sum256([]byte(challenge + ":" + solution)) {
+		n += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return n
+}
+
+func assertErrorCode(t *testing.T, code string, err error) {
+	t.Helper()
+
+	mberr, ok := err.(*messageboard.Error)
+	if assert.True(t, ok, "expected *messageboard.Error, got: %v", err) {
+		assert.Equal(t, messageboard.CategoryForbidden, mberr.Category)
+		assert.Equal(t, code, mberr.Code)
+	}
+}
+
+func TestMessageBoardHandler_Challenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), messageboard.DefaultBoardID).
+		Return(messageboard.DefaultBoard(), nil).
+		Times(2)
+	svc.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&messageboard.Message{ID: "my-id"}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials,
+		mbhttp.WithProofOfWork(mbhttp.NewProofOfWork([]byte("secret"), 4, time.Minute, mbhttp.NewMemoryReplayCache())),
+	)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/challenge", nil)
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	var c mbhttp.Challenge
+	err := json.NewDecoder(w.Body).Decode(&c)
+	require.NoError(t, err)
+	assert.Equal(t, 4, c.Difficulty)
+
+	body := `{"name": "Guilherme", "email": "xguiga@gmail.com", "text": "My text goes here"}`
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(body))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{
+		"code": "challenge_required",
+		"message": "headers \"X-Challenge\" and \"X-Challenge-Solution\" are required, get a challenge at /v1/challenge"
+	}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(body))
+	req.Header.Set("X-Challenge", c.Challenge)
+	req.Header.Set("X-Challenge-Solution", solve(c.Challenge, c.Difficulty))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestMessageBoardHandler_ChallengeDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, mock.NewService(ctrl), credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/challenge", nil)
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMemoryReplayCache(t *testing.T) {
+	c := mbhttp.NewMemoryReplayCache()
+
+	assert.True(t, c.Add("key", time.Now().Add(time.Minute)))
+	assert.False(t, c.Add("key", time.Now().Add(time.Minute)))
+
+	// Expired keys can be added again.
+	assert.True(t, c.Add("expired", time.Now().Add(-time.Second)))
+	assert.True(t, c.Add("expired", time.Now().Add(time.Minute)))
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+)
+
+// etag returns the entity tag of msg, which is its quoted version. Moderation
+// doesn't change the version, so the moderation time, in milliseconds, is
+// appended to it.
+func etag(msg *messageboard.Message) string {
+	tag := strconv.FormatInt(msg.Version, 10)
+	if msg.ModerationTime != nil {
+		tag += "-" + strconv.FormatInt(msg.ModerationTime.UnixNano()/int64(time.Millisecond), 10)
+	}
+	return strconv.Quote(tag)
+}
+
+func setETag(w http.ResponseWriter, msg *messageboard.Message) {
+	w.Header().Set("ETag", etag(msg))
+}
+
+// matchETag reports if the If-Match header matches the entity tag of msg,
+// it's a comma separated list of entity tags or "*". As required by RFC 7232,
+// the comparison is strong, so weak tags never match.
+func matchETag(ifMatch string, msg *messageboard.Message) bool {
+	current := etag(msg)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+func setLastModified(w http.ResponseWriter, msg *messageboard.Message) {
+	w.Header().Set("Last-Modified", msg.LastModified().UTC().Format(http.TimeFormat))
+}
+
+// notModified reports if msg was not modified since the time in the
+// If-Modified-Since header, if it's present and valid.
+func notModified(req *http.Request, msg *messageboard.Message) bool {
+	v := req.Header.Get("If-Modified-Since")
+	if v == "" {
+		return false
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return false
+	}
+	// Http dates have seconds precision.
+	return !msg.LastModified().Truncate(time.Second).After(t)
+}
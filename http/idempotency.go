@@ -0,0 +1,139 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+)
+
+const (
+	// DefaultIdempotencyTTL is the default of WithIdempotencyStore.
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// MaxIdempotencyKeyLength is the maximum length of the Idempotency-Key header.
+	MaxIdempotencyKeyLength = 255
+)
+
+// WithIdempotencyStore allows the clients to safely retry the requests creating
+// messages, sending the same Idempotency-Key header, the response of the first
+// request is returned to the following ones for ttl, or DefaultIdempotencyTTL
+// when ttl is not positive. By default the header is ignored.
+func WithIdempotencyStore(store messageboard.IdempotencyStore, ttl time.Duration) HandlerOption {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return func(h *MessageBoardHandler) {
+		h.idempotencyStore = store
+		h.idempotencyTTL = ttl
+	}
+}
+
+// responseRecorder keeps the status code and the body written to the response.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// idempotent replays the response of the requests with an Idempotency-Key
+// already used, only successful responses are kept, so failed requests can be
+// retried with the same key.
+func (h *MessageBoardHandler) idempotent(next http.Handler) http.Handler {
+	if h.idempotencyStore == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		key := req.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if len(key) > MaxIdempotencyKeyLength {
+			responseError(w, req, messageboard.NewValidationError("invalid_idempotency_key", "header \"Idempotency-Key\" must have at most 255 characters"))
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, MaxBodySize))
+		if err != nil {
+			responseError(w, req, messageboard.NewValidationError("invalid_body", err.Error()))
+			return
+		}
+		// The body still needs to be read by the handler.
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		// The same key can't be used in another endpoint or with another body.
+		h256 := sha256.New()
+		h256.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+		h256.Write(body)
+		hash := hex.EncodeToString(h256.Sum(nil))
+
+		saved, err := h.idempotencyStore.ReserveKey(ctx, &messageboard.IdempotencyKey{
+			Key:            key,
+			RequestHash:    hash,
+			ExpirationTime: time.Now().UTC().Add(h.idempotencyTTL).Truncate(time.Millisecond),
+		})
+		if err != nil {
+			responseError(w, req, err)
+			return
+		}
+		if saved != nil {
+			switch {
+			case saved.RequestHash != hash:
+				responseError(w, req, messageboard.NewValidationError("idempotency_key_reused", "header \"Idempotency-Key\" was already used by another request"))
+			case !saved.Completed():
+				responseError(w, req, messageboard.NewConflictError("idempotency_key_in_use", "a request with the same \"Idempotency-Key\" is still running"))
+			default:
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(saved.StatusCode)
+				w.Write(saved.Body)
+			}
+			return
+		}
+
+		// The key is released when the request fails, even by a panic, so it
+		// can be retried.
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			err := h.idempotencyStore.ReleaseKey(ctx, key)
+			if err != nil {
+				log.Println("unable to release idempotency key:", err)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, req)
+
+		if rec.statusCode >= 200 && rec.statusCode < 300 {
+			completed = true
+			err = h.idempotencyStore.CompleteKey(ctx, key, rec.statusCode, rec.body.Bytes())
+			if err != nil {
+				// The response was already sent, at worst the key can't be used again.
+				log.Println("unable to save idempotency key:", err)
+			}
+		}
+	})
+}
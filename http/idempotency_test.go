@@ -0,0 +1,189 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+	mbhttp "github.com/guilherme-santos/messageboard/http"
+	"github.com/guilherme-santos/messageboard/memory"
+	"github.com/guilherme-santos/messageboard/mock"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageBoardHandler_Idempotency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), messageboard.DefaultBoardID).
+		Return(messageboard.DefaultBoard(), nil).
+		AnyTimes()
+	gomock.InOrder(
+		svc.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			Return(nil, messageboard.NewInternalError("internal", "something went wrong")),
+		svc.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			Return(&messageboard.Message{
+				ID:           "my-id",
+				Name:         "Guilherme",
+				Email:        "xguiga@gmail.com",
+				Text:         "My text goes here",
+				CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+			}, nil),
+	)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials,
+		mbhttp.WithIdempotencyStore(memory.NewMessageBoardStorage(), time.Hour),
+	)
+
+	create := func(key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+
+		router.ServeHTTP(w, req)
+		return w
+	}
+	body := `{"name": "Guilherme", "email": "xguiga@gmail.com", "text": "My text goes here"}`
+	expBody := `{
+		"id": "my-id",
+		"name": "Guilherme",
+		"email": "xguiga@gmail.com",
+		"text": "My text goes here",
+		"creation_time": "2020-08-12T15:30:00Z"
+	}`
+
+	// Failed requests can be retried with the same key.
+	w := create("my-key", body)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = create("my-key", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, expBody, w.Body.String())
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+
+	// The message is not created again.
+	w = create("my-key", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, expBody, w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+
+	w = create("my-key", `{"name": "Guilherme", "email": "xguiga@gmail.com", "text": "Other text"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"code": "idempotency_key_reused",
+		"message": "header \"Idempotency-Key\" was already used by another request"
+	}`, w.Body.String())
+
+	w = create(strings.Repeat("k", mbhttp.MaxIdempotencyKeyLength+1), body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_idempotency_key")
+}
+
+func TestMessageBoardHandler_IdempotencyInUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	expectDefaultBoard(svc)
+
+	// A request with the same key and body, {}, is still running.
+	store := memory.NewMessageBoardStorage()
+	_, err := store.ReserveKey(context.Background(), &messageboard.IdempotencyKey{
+		Key:            "my-key",
+		RequestHash:    "c5026f884f0cc0405d81fcc3fa45bfec8d4c9cd78d5f8279356967fd191029f1",
+		ExpirationTime: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials,
+		mbhttp.WithIdempotencyStore(store, time.Hour),
+	)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(`{}`))
+	req.Header.Set("Idempotency-Key", "my-key")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "idempotency_key_in_use")
+}
+
+func TestMessageBoardHandler_IdempotencyPanic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), messageboard.DefaultBoardID).
+		Return(messageboard.DefaultBoard(), nil).
+		Times(2)
+	gomock.InOrder(
+		svc.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, msg *messageboard.Message) (*messageboard.Message, error) {
+				panic("something went wrong")
+			}),
+		svc.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			Return(&messageboard.Message{ID: "my-id"}, nil),
+	)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials,
+		mbhttp.WithIdempotencyStore(memory.NewMessageBoardStorage(), time.Hour),
+	)
+
+	create := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "my-key")
+
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Panics(t, func() { create() })
+
+	// The key was released, so the request can be retried.
+	w := create()
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestMessageBoardHandler_IdempotencyBodyTooBig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	expectDefaultBoard(svc)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials,
+		mbhttp.WithIdempotencyStore(memory.NewMessageBoardStorage(), time.Hour),
+	)
+
+	w := httptest.NewRecorder()
+	body := `{"text": "` + strings.Repeat("a", mbhttp.MaxBodySize) + `"}`
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", "my-key")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_body")
+}
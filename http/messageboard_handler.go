@@ -4,8 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/guilherme-santos/messageboard"
 
@@ -13,39 +19,156 @@ import (
 )
 
 type MessageBoardHandler struct {
-	svc messageboard.Service
+	svc            messageboard.Service
+	auth           func(http.Handler) http.Handler
+	errorFormat    ErrorFormat
+	maxThreadDepth int
+	// tenantResolvers resolve the tenant of each request, which must be in knownTenants.
+	tenantResolvers []TenantResolver
+	knownTenants    map[string]bool
+	// rateLimiter limits the messages created by each client, identified by rateLimitKey.
+	rateLimiter  RateLimiter
+	rateLimitKey RateLimitKey
+	// idempotencyStore keeps the Idempotency-Key of the requests creating messages for idempotencyTTL.
+	idempotencyStore messageboard.IdempotencyStore
+	idempotencyTTL   time.Duration
+	// pow issues the challenges anonymous clients solve before creating messages.
+	pow *ProofOfWork
 }
 
-func NewMessageBoardHandler(r chi.Router, svc messageboard.Service, creds map[string]string) *MessageBoardHandler {
+// DefaultMaxThreadDepth is the default of WithMaxThreadDepth.
+const DefaultMaxThreadDepth = 5
+
+// MaxBodySize is the maximum size of the bodies read before reaching the
+// handlers, e.g. to rate limit by email, it's way more than a message needs.
+const MaxBodySize = 64 << 10
+
+// HandlerOption configures optional behaviours of MessageBoardHandler.
+type HandlerOption func(*MessageBoardHandler)
+
+// WithErrorFormat changes the default format of the errors, by default ErrorFormatJSON is used.
+func WithErrorFormat(format ErrorFormat) HandlerOption {
+	return func(h *MessageBoardHandler) {
+		h.errorFormat = format
+	}
+}
+
+// WithMaxThreadDepth changes the maximum depth of the replies returned by the
+// thread endpoint, by default DefaultMaxThreadDepth is used.
+func WithMaxThreadDepth(depth int) HandlerOption {
+	return func(h *MessageBoardHandler) {
+		h.maxThreadDepth = depth
+	}
+}
+
+func NewMessageBoardHandler(r chi.Router, svc messageboard.Service, creds map[string]string, opts ...HandlerOption) *MessageBoardHandler {
 	h := &MessageBoardHandler{
-		svc: svc,
+		svc:            svc,
+		maxThreadDepth: DefaultMaxThreadDepth,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
-	// Register create endpoint without authentication.
-	r.Post("/v1/messages", h.create)
+
+	r = r.With(errorFormat(h.errorFormat), resolveTenant(h.tenantResolvers, h.knownTenants))
 
 	// Here we're using a basic auth, but we could use a JWT token, which at least
 	// will validate the token, still the permissions could be inside of each service/endpoint.
+	h.auth = BasicAuth("Back's Message Board", creds)
+
+	authRouter := r.With(h.auth)
+	authRouter.Get("/v1/boards", h.listBoards)
+	authRouter.Post("/v1/boards", h.createBoard)
+	authRouter.Get("/v1/boards/{board}", h.getBoard)
+	authRouter.Put("/v1/boards/{board}", h.updateBoard)
+	authRouter.Delete("/v1/boards/{board}", h.deleteBoard)
+
+	if h.pow != nil {
+		r.Get("/v1/challenge", h.issueChallenge)
+	}
+
+	// Routes without board are for the messages in the default board.
+	h.messageRoutes(r, "/v1")
+	h.messageRoutes(r.With(h.loadBoard), "/v1/boards/{board}")
+	return h
+}
+
+// messageRoutes registers the message endpoints under prefix.
+func (h *MessageBoardHandler) messageRoutes(r chi.Router, prefix string) {
+	// Register create endpoints without authentication, unless the board is private.
+	r.With(h.rateLimit, h.boardAuth, h.idempotent, h.requireChallenge).Post(prefix+"/messages", h.create)
+	r.With(h.rateLimit, h.boardAuth, h.idempotent, h.requireChallenge).Post(prefix+"/messages/{id}/replies", h.createReply)
+
+	authRouter := r.With(h.auth)
+	authRouter.Get(prefix+"/messages", h.list)
+	authRouter.Get(prefix+"/trash", h.listTrash)
+	authRouter.Get(prefix+"/tags", h.listTags)
+	authRouter.Route(prefix+"/messages/{id}", func(r chi.Router) {
+		// Deleted messages are not found by loadMessage, so restore needs to be
+		// registered before it.
+		r.Post("/restore", h.restore)
+		r.Get("/thread", h.thread)
 
-	authRouter := r.With(BasicAuth("Back's Message Board", creds))
-	authRouter.Get("/v1/messages", h.list)
-	authRouter.Route("/v1/messages/{id}", func(r chi.Router) {
 		// Add a middleware that will be called in all following endpoints.
 		r = r.With(h.loadMessage)
 		r.Get("/", h.get)
 		r.Put("/", h.update)
+		r.Patch("/", h.patch)
+		r.Get("/replies", h.listReplies)
+		r.Delete("/", h.delete)
+		r.Post("/approve", h.moderate(messageboard.StatusApproved))
+		r.Post("/reject", h.moderate(messageboard.StatusRejected))
+		r.Get("/revisions", h.listRevisions)
+		r.Get("/revisions/{version}", h.getRevision)
+		r.Post("/revisions/{version}/revert", h.revert)
 	})
-	return h
 }
 
 func (h *MessageBoardHandler) list(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 
 	opts := new(messageboard.ListOptions)
-	opts.Load(req.URL.Query())
+	err := opts.Load(req.URL.Query())
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	opts.BoardID = boardID(ctx)
+
+	list, err := h.svc.List(ctx, opts)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, list)
+}
+
+func (h *MessageBoardHandler) listTrash(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	opts := new(messageboard.ListOptions)
+	err := opts.Load(req.URL.Query())
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	opts.BoardID = boardID(ctx)
+	opts.Deleted = true
 
 	list, err := h.svc.List(ctx, opts)
 	if err != nil {
-		responseError(w, err)
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, list)
+}
+
+func (h *MessageBoardHandler) listTags(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	list, err := h.svc.Tags(ctx, boardID(ctx))
+	if err != nil {
+		responseError(w, req, err)
 		return
 	}
 	responseJSON(w, http.StatusOK, list)
@@ -57,30 +180,122 @@ func (h *MessageBoardHandler) create(w http.ResponseWriter, req *http.Request) {
 	var reqMsg *messageboard.Message
 	err := json.NewDecoder(req.Body).Decode(&reqMsg)
 	if err != nil {
-		responseError(w, messageboard.NewError("invalid_json", err.Error()))
+		responseError(w, req, messageboard.NewValidationError("invalid_json", err.Error()))
 		return
 	}
+	if reqMsg == nil {
+		responseError(w, req, messageboard.NewValidationError("invalid_json", "body must be a message"))
+		return
+	}
+	reqMsg.BoardID = boardID(ctx)
 
 	msg, err := h.svc.Create(ctx, reqMsg)
 	if err != nil {
-		responseError(w, err)
+		responseError(w, req, err)
 		return
 	}
-	responseJSON(w, http.StatusCreated, msg)
+	responseCreated(w, msg)
+}
+
+func (h *MessageBoardHandler) createReply(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	var reqMsg *messageboard.Message
+	err := json.NewDecoder(req.Body).Decode(&reqMsg)
+	if err != nil {
+		responseError(w, req, messageboard.NewValidationError("invalid_json", err.Error()))
+		return
+	}
+	if reqMsg == nil {
+		responseError(w, req, messageboard.NewValidationError("invalid_json", "body must be a message"))
+		return
+	}
+	reqMsg.BoardID = boardID(ctx)
+	reqMsg.ParentID = chi.URLParamFromCtx(ctx, "id")
+
+	msg, err := h.svc.Create(ctx, reqMsg)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseCreated(w, msg)
+}
+
+// responseCreated responds msg as created, unless it was merged with an
+// existing message, see messageboard.DuplicateMerge.
+func responseCreated(w http.ResponseWriter, msg *messageboard.Message) {
+	statusCode := http.StatusCreated
+	if msg.Merged {
+		statusCode = http.StatusOK
+	}
+	responseJSON(w, statusCode, msg)
+}
+
+func (h *MessageBoardHandler) listReplies(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	msg := ctx.Value(msgCtxKey).(*messageboard.Message)
+
+	opts := new(messageboard.ListOptions)
+	err := opts.Load(req.URL.Query())
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	opts.ParentID = msg.ID
+
+	list, err := h.svc.List(ctx, opts)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, list)
+}
+
+// thread returns the message with its replies nested up to the depth in the
+// query string, which can't be greater than the maximum depth.
+func (h *MessageBoardHandler) thread(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	depth := h.maxThreadDepth
+	if v := req.URL.Query().Get("depth"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 0 || d > h.maxThreadDepth {
+			responseError(w, req, messageboard.NewValidationError("invalid_depth", fmt.Sprintf(`query string "depth" must be a number between 0 and %d`, h.maxThreadDepth)))
+			return
+		}
+		depth = d
+	}
+
+	id := chi.URLParamFromCtx(ctx, "id")
+	msg, err := h.svc.Thread(ctx, id, depth)
+	if err == nil && !msg.InBoard(boardID(ctx)) {
+		err = errMessageNotFound
+	}
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, msg)
 }
 
 type contextKey string
 
 var msgCtxKey = contextKey("message")
 
+// errMessageNotFound is returned for messages of other boards.
+var errMessageNotFound = messageboard.NewNotFoundError("not_found", "message was not found")
+
 func (h *MessageBoardHandler) loadMessage(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
 		id := chi.URLParamFromCtx(ctx, "id")
 		u, err := h.svc.Get(ctx, id)
+		if err == nil && !u.InBoard(boardID(ctx)) {
+			err = errMessageNotFound
+		}
 		if err != nil {
-			responseError(w, err)
+			responseError(w, r, err)
 			return
 		}
 
@@ -94,6 +309,12 @@ func (h *MessageBoardHandler) loadMessage(next http.Handler) http.Handler {
 func (h *MessageBoardHandler) get(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	msg := ctx.Value(msgCtxKey).(*messageboard.Message)
+	setETag(w, msg)
+	setLastModified(w, msg)
+	if notModified(req, msg) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	responseJSON(w, http.StatusOK, msg)
 }
 
@@ -103,7 +324,11 @@ func (h *MessageBoardHandler) update(w http.ResponseWriter, req *http.Request) {
 	var reqMsg *messageboard.Message
 	err := json.NewDecoder(req.Body).Decode(&reqMsg)
 	if err != nil {
-		responseError(w, messageboard.NewError("invalid_json", err.Error()))
+		responseError(w, req, messageboard.NewValidationError("invalid_json", err.Error()))
+		return
+	}
+	if reqMsg == nil {
+		responseError(w, req, messageboard.NewValidationError("invalid_json", "body must be a message"))
 		return
 	}
 
@@ -118,16 +343,216 @@ func (h *MessageBoardHandler) update(w http.ResponseWriter, req *http.Request) {
 	// updated, like id and creation_time (it'll be ignored anyways)
 	reqMsg.ID = currentMsg.ID
 
+	err = checkIfMatch(req, currentMsg, reqMsg)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+
 	msg, err := h.svc.Update(ctx, reqMsg)
 	if err != nil {
-		responseError(w, err)
+		responseError(w, req, err)
 		return
 	}
+	setETag(w, msg)
+	setLastModified(w, msg)
 	responseJSON(w, http.StatusCreated, msg)
 }
 
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// patch changes only the fields in the patch, which can be a JSON Merge Patch or
+// a JSON Patch, depending on the content type.
+func (h *MessageBoardHandler) patch(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	currentMsg := ctx.Value(msgCtxKey).(*messageboard.Message)
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+
+	patched := *currentMsg
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case mergePatchType:
+		err = patched.ApplyMergePatch(body)
+	case jsonPatchType:
+		err = patched.ApplyJSONPatch(body)
+	default:
+		w.Header().Set("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		err = messageboard.NewUnsupportedError("unsupported_media_type", "Content-Type must be "+mergePatchType+" or "+jsonPatchType)
+	}
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+
+	// Only the fields allowed in PUT can be changed. The patch was applied to
+	// the current version, so it's always checked by the storage, this way the
+	// changes of someone else are never overwritten.
+	reqMsg := &messageboard.Message{
+		ID:      currentMsg.ID,
+		Name:    patched.Name,
+		Email:   patched.Email,
+		Text:    patched.Text,
+		Tags:    patched.Tags,
+		Version: currentMsg.Version,
+	}
+	err = checkIfMatch(req, currentMsg, reqMsg)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+
+	msg, err := h.svc.Update(ctx, reqMsg)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	setETag(w, msg)
+	setLastModified(w, msg)
+	responseJSON(w, http.StatusOK, msg)
+}
+
+// checkIfMatch checks the If-Match header against the current message, if it's
+// present. The message could still be updated by someone else until we update
+// it, so the version is also set in reqMsg to be checked again by the storage.
+func checkIfMatch(req *http.Request, currentMsg, reqMsg *messageboard.Message) error {
+	v := req.Header.Get("If-Match")
+	if v == "" {
+		return nil
+	}
+	if !matchETag(v, currentMsg) {
+		return messageboard.NewPreconditionError("precondition_failed", "If-Match doesn't match the current ETag of the message")
+	}
+	reqMsg.Version = currentMsg.Version
+	return nil
+}
+
+func (h *MessageBoardHandler) delete(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	msg := ctx.Value(msgCtxKey).(*messageboard.Message)
+
+	err := h.svc.Delete(ctx, msg.ID)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MessageBoardHandler) restore(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	id := chi.URLParamFromCtx(ctx, "id")
+	msg, err := h.svc.Restore(ctx, boardID(ctx), id)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, msg)
+}
+
+// moderate returns a handler changing the status of the message loaded by
+// loadMessage to status, the body may have the reason.
+func (h *MessageBoardHandler) moderate(status messageboard.Status) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		msg := ctx.Value(msgCtxKey).(*messageboard.Message)
+
+		m := new(messageboard.Moderation)
+		err := json.NewDecoder(req.Body).Decode(m)
+		if err != nil && err != io.EOF {
+			responseError(w, req, messageboard.NewValidationError("invalid_json", err.Error()))
+			return
+		}
+		m.Status = status
+
+		msg, err = h.svc.Moderate(ctx, msg.ID, m)
+		if err != nil {
+			responseError(w, req, err)
+			return
+		}
+		responseJSON(w, http.StatusOK, msg)
+	}
+}
+
+func (h *MessageBoardHandler) listRevisions(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	msg := ctx.Value(msgCtxKey).(*messageboard.Message)
+
+	list, err := h.svc.Revisions(ctx, msg.ID)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, list)
+}
+
+// loadRevision returns the revision in the url of the message loaded by loadMessage.
+func (h *MessageBoardHandler) loadRevision(req *http.Request) (*messageboard.Revision, error) {
+	ctx := req.Context()
+	msg := ctx.Value(msgCtxKey).(*messageboard.Message)
+
+	version, err := strconv.ParseInt(chi.URLParamFromCtx(ctx, "version"), 10, 64)
+	if err != nil {
+		return nil, messageboard.NewNotFoundError("not_found", "revision was not found")
+	}
+	return h.svc.Revision(ctx, msg.ID, version)
+}
+
+func (h *MessageBoardHandler) getRevision(w http.ResponseWriter, req *http.Request) {
+	rev, err := h.loadRevision(req)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	responseJSON(w, http.StatusOK, rev)
+}
+
+// revert updates the message with the content of a revision, the current content
+// is kept as a new revision, as in any other update.
+func (h *MessageBoardHandler) revert(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	currentMsg := ctx.Value(msgCtxKey).(*messageboard.Message)
+
+	rev, err := h.loadRevision(req)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+
+	reqMsg := &messageboard.Message{
+		ID:    currentMsg.ID,
+		Name:  rev.Name,
+		Email: rev.Email,
+		Text:  rev.Text,
+		Tags:  rev.Tags,
+	}
+	err = checkIfMatch(req, currentMsg, reqMsg)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+
+	msg, err := h.svc.Update(ctx, reqMsg)
+	if err != nil {
+		responseError(w, req, err)
+		return
+	}
+	setETag(w, msg)
+	setLastModified(w, msg)
+	responseJSON(w, http.StatusOK, msg)
+}
+
 // responseError inspects the error and convert it into a meaningful status code and message.
-func responseError(w http.ResponseWriter, err error) {
+// The body format is negotiated with the client, see wantsProblem.
+func responseError(w http.ResponseWriter, req *http.Request, err error) {
 	var mberr *messageboard.Error
 	if !errors.As(err, &mberr) {
 		mberr = new(messageboard.Error)
@@ -135,18 +560,38 @@ func responseError(w http.ResponseWriter, err error) {
 		mberr.Message = err.Error()
 	}
 
-	var statusCode int
-	switch mberr.Code {
-	case "not_found":
-		statusCode = http.StatusNotFound
-	case "unauthorized":
-		statusCode = http.StatusUnauthorized
-	default:
-		statusCode = http.StatusInternalServerError
+	statusCode := statusCode(messageboard.ErrorCategoryOf(mberr))
+	if wantsProblem(req) {
+		responseProblem(w, newProblem(req, statusCode, mberr))
+		return
 	}
 	responseJSON(w, statusCode, mberr)
 }
 
+// statusCode maps an error category into a http status code.
+func statusCode(cat messageboard.ErrorCategory) int {
+	switch cat {
+	case messageboard.CategoryValidation:
+		return http.StatusBadRequest
+	case messageboard.CategoryConflict:
+		return http.StatusConflict
+	case messageboard.CategoryPrecondition:
+		return http.StatusPreconditionFailed
+	case messageboard.CategoryNotFound:
+		return http.StatusNotFound
+	case messageboard.CategoryUnauthorized:
+		return http.StatusUnauthorized
+	case messageboard.CategoryForbidden:
+		return http.StatusForbidden
+	case messageboard.CategoryRateLimited:
+		return http.StatusTooManyRequests
+	case messageboard.CategoryUnsupported:
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // responseError responds the http call with the status code and the body as json.
 func responseJSON(w http.ResponseWriter, statusCode int, body interface{}) {
 	if body != nil {
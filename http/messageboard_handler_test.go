@@ -2,9 +2,12 @@ package http_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,6 +31,7 @@ func TestMessageBoardHandler_List(t *testing.T) {
 	svc := mock.NewService(ctrl)
 	svc.EXPECT().
 		List(gomock.Any(), &messageboard.ListOptions{
+			BoardID: messageboard.DefaultBoardID,
 			PerPage: 10,
 			Page:    2,
 		}).
@@ -76,12 +80,14 @@ func TestMessageBoardHandler_Create(t *testing.T) {
 	defer ctrl.Finish()
 
 	reqMsg := &messageboard.Message{
-		Name:  "Guilherme",
-		Email: "xguiga@gmail.com",
-		Text:  "My text goes here",
+		BoardID: messageboard.DefaultBoardID,
+		Name:    "Guilherme",
+		Email:   "xguiga@gmail.com",
+		Text:    "My text goes here",
 	}
 
 	svc := mock.NewService(ctrl)
+	expectDefaultBoard(svc)
 	svc.EXPECT().
 		Create(gomock.Any(), reqMsg).
 		Return(&messageboard.Message{
@@ -114,6 +120,150 @@ func TestMessageBoardHandler_Create(t *testing.T) {
 	}`, w.Body.String())
 }
 
+func TestMessageBoardHandler_CreateReply(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	expectDefaultBoard(svc)
+	svc.EXPECT().
+		Create(gomock.Any(), &messageboard.Message{
+			BoardID:  messageboard.DefaultBoardID,
+			Name:     "Guilherme",
+			Email:    "xguiga@gmail.com",
+			Text:     "My reply goes here",
+			ParentID: "my-id",
+		}).
+		Return(&messageboard.Message{
+			ID:           "reply-id",
+			Name:         "Guilherme",
+			Email:        "xguiga@gmail.com",
+			Text:         "My reply goes here",
+			ParentID:     "my-id",
+			CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	// Replies are public as any other message, parent_id in the body is ignored.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages/my-id/replies", strings.NewReader(`{
+		"name": "Guilherme",
+		"email": "xguiga@gmail.com",
+		"text": "My reply goes here",
+		"parent_id": "other-id"
+	}`))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{
+		"id": "reply-id",
+		"name": "Guilherme",
+		"email": "xguiga@gmail.com",
+		"text": "My reply goes here",
+		"parent_id": "my-id",
+		"creation_time": "2020-08-12T15:30:00Z"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_ListReplies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{ID: "my-id", ReplyCount: 3}, nil)
+	svc.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{
+			PerPage:  2,
+			Page:     2,
+			ParentID: "my-id",
+		}).
+		Return(&messageboard.MessageList{
+			Total: 3,
+			Data: []*messageboard.Message{
+				{ID: "reply-id", ParentID: "my-id"},
+			},
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages/my-id/replies?per_page=2&page=2", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"total": 3,
+		"data": [{
+			"id": "reply-id",
+			"name": "",
+			"email": "",
+			"text": "",
+			"parent_id": "my-id",
+			"creation_time": "0001-01-01T00:00:00Z"
+		}]
+	}`, w.Body.String())
+
+	// Listing replies requires authentication.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost/v1/messages/my-id/replies", nil)
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestMessageBoardHandler_Thread(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Thread(gomock.Any(), "my-id", mbhttp.DefaultMaxThreadDepth).
+		Return(&messageboard.Message{ID: "my-id", ReplyCount: 1}, nil)
+	svc.EXPECT().
+		Thread(gomock.Any(), "my-id", 1).
+		Return(&messageboard.Message{
+			ID:         "my-id",
+			ReplyCount: 1,
+			Replies: []*messageboard.Message{
+				{ID: "reply-id", ParentID: "my-id"},
+			},
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	tests := []struct {
+		query      string
+		statusCode int
+	}{
+		{"", http.StatusOK},
+		{"?depth=1", http.StatusOK},
+		{"?depth=6", http.StatusBadRequest},
+		{"?depth=-1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages/my-id/thread"+tt.query, nil)
+		req.SetBasicAuth("test", "testpasswd")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.statusCode, w.Code, tt.query)
+		if tt.query == "?depth=1" {
+			assert.Contains(t, w.Body.String(), `"replies":[{"id":"reply-id"`)
+		}
+	}
+}
+
 func TestMessageBoardHandler_Get(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -127,6 +277,7 @@ func TestMessageBoardHandler_Get(t *testing.T) {
 			Email:        "xguiga@gmail.com",
 			Text:         "My text goes here",
 			CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+			Version:      3,
 		}, nil)
 
 	router := chi.NewRouter()
@@ -139,15 +290,109 @@ func TestMessageBoardHandler_Get(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	assert.Equal(t, "Wed, 12 Aug 2020 15:30:00 GMT", w.Header().Get("Last-Modified"))
 	assert.JSONEq(t, `{
 		"id": "my-id",
 		"name": "Guilherme",
 		"email": "xguiga@gmail.com",
 		"text": "My text goes here",
-		"creation_time": "2020-08-12T15:30:00Z"
+		"creation_time": "2020-08-12T15:30:00Z",
+		"version": 3
 	}`, w.Body.String())
 }
 
+func TestMessageBoardHandler_GetIfModifiedSince(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	updateTime := time.Date(2020, time.August, 13, 10, 0, 0, 500, time.UTC)
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{
+			ID:           "my-id",
+			Name:         "Guilherme",
+			Email:        "xguiga@gmail.com",
+			Text:         "My text was updated",
+			CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+			Version:      2,
+			UpdateTime:   &updateTime,
+			UpdatedBy:    "test",
+		}, nil).
+		AnyTimes()
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	tests := []struct {
+		ifModifiedSince string
+		statusCode      int
+	}{
+		{"", http.StatusOK},
+		{"Thu, 13 Aug 2020 09:59:59 GMT", http.StatusOK},
+		{"Thu, 13 Aug 2020 10:00:00 GMT", http.StatusNotModified},
+		{"Fri, 14 Aug 2020 00:00:00 GMT", http.StatusNotModified},
+		{"invalid", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages/my-id", nil)
+		if tt.ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+		}
+		req.SetBasicAuth("test", "testpasswd")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.statusCode, w.Code, tt.ifModifiedSince)
+		assert.Equal(t, "Thu, 13 Aug 2020 10:00:00 GMT", w.Header().Get("Last-Modified"))
+		if tt.statusCode == http.StatusNotModified {
+			assert.Empty(t, w.Body.String())
+		} else {
+			assert.Contains(t, w.Body.String(), `"updated_by":"test"`)
+			assert.Contains(t, w.Body.String(), `"update_time":"2020-08-13T10:00:00.0000005Z"`)
+		}
+	}
+}
+
+func TestMessageBoardHandler_GetModerated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	updateTime := time.Date(2020, time.August, 13, 10, 0, 0, 0, time.UTC)
+	moderationTime := time.Date(2020, time.August, 14, 9, 0, 0, 0, time.UTC)
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{
+			ID:             "my-id",
+			CreationTime:   time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+			Version:        2,
+			UpdateTime:     &updateTime,
+			Status:         messageboard.StatusApproved,
+			ModerationTime: &moderationTime,
+			ModeratedBy:    "test",
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	// The message was moderated after the update.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages/my-id", nil)
+	req.Header.Set("If-Modified-Since", "Thu, 13 Aug 2020 10:00:00 GMT")
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Fri, 14 Aug 2020 09:00:00 GMT", w.Header().Get("Last-Modified"))
+	assert.Equal(t, `"2-1597395600000"`, w.Header().Get("ETag"))
+}
+
 func TestMessageBoardHandler_GetNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -226,27 +471,1344 @@ func TestMessageBoardHandler_Update(t *testing.T) {
 	}`, w.Body.String())
 }
 
-func TestMessageBoardHandler_UpdateNotFound(t *testing.T) {
+func TestMessageBoardHandler_UpdateIfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqMsg := &messageboard.Message{
+		ID:    "my-id",
+		Name:  "Guilherme",
+		Email: "xguiga@gmail.com",
+		Text:  "My text was updated",
+	}
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{
+			ID:      "my-id",
+			Name:    "Guilherme",
+			Email:   "xguiga@gmail.com",
+			Text:    "My text goes here",
+			Version: 3,
+		}, nil)
+	// The version of the ETag is passed to the storage, which checks it again.
+	svc.EXPECT().
+		Update(gomock.Any(), &messageboard.Message{
+			ID:      "my-id",
+			Name:    "Guilherme",
+			Email:   "xguiga@gmail.com",
+			Text:    "My text was updated",
+			Version: 3,
+		}).
+		Return(&messageboard.Message{
+			ID:      "my-id",
+			Name:    "Guilherme",
+			Email:   "xguiga@gmail.com",
+			Text:    "My text was updated",
+			Version: 4,
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(reqMsg)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost/v1/messages/my-id", &buf)
+	req.Header.Set("If-Match", `"2", "3"`)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+}
+
+func TestMessageBoardHandler_UpdatePreconditionFailed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	svc := mock.NewService(ctrl)
 	svc.EXPECT().
 		Get(gomock.Any(), "my-id").
-		Return(nil, messageboard.NewError("not_found", "message not found"))
+		Return(&messageboard.Message{
+			ID:      "my-id",
+			Name:    "Guilherme",
+			Email:   "xguiga@gmail.com",
+			Text:    "My text goes here",
+			Version: 3,
+		}, nil).
+		Times(2)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	for _, ifMatch := range []string{`"2"`, `W/"3"`} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "http://localhost/v1/messages/my-id", strings.NewReader(`{"text": "My text was updated"}`))
+		req.Header.Set("If-Match", ifMatch)
+		req.SetBasicAuth("test", "testpasswd")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code, ifMatch)
+		assert.JSONEq(t, `{
+			"code": "precondition_failed",
+			"message": "If-Match doesn't match the current ETag of the message"
+		}`, w.Body.String())
+	}
+}
+
+func TestMessageBoardHandler_Revisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{ID: "my-id", Version: 2}, nil)
+	svc.EXPECT().
+		Revisions(gomock.Any(), "my-id").
+		Return(&messageboard.RevisionList{
+			Total: 1,
+			Data: []*messageboard.Revision{
+				{
+					MessageID:  "my-id",
+					Version:    1,
+					Name:       "Guilherme",
+					Email:      "xguiga@gmail.com",
+					Text:       "My text goes here",
+					ChangeTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+					ChangedBy:  "test",
+				},
+			},
+		}, nil)
 
 	router := chi.NewRouter()
 	mbhttp.NewMessageBoardHandler(router, svc, credentials)
 
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest(http.MethodPut, "http://localhost/v1/messages/my-id", nil)
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages/my-id/revisions", nil)
 	req.SetBasicAuth("test", "testpasswd")
 
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, http.StatusOK, w.Code)
 	assert.JSONEq(t, `{
-		"code": "not_found",
-		"message": "message not found"
+		"total": 1,
+		"data": [{
+			"message_id": "my-id",
+			"version": 1,
+			"name": "Guilherme",
+			"email": "xguiga@gmail.com",
+			"text": "My text goes here",
+			"change_time": "2020-08-12T15:30:00Z",
+			"changed_by": "test"
+		}]
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_Revision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{ID: "my-id", Version: 2}, nil).
+		Times(3)
+	svc.EXPECT().
+		Revision(gomock.Any(), "my-id", int64(1)).
+		Return(&messageboard.Revision{MessageID: "my-id", Version: 1, Text: "My text goes here"}, nil)
+	svc.EXPECT().
+		Revision(gomock.Any(), "my-id", int64(5)).
+		Return(nil, messageboard.NewNotFoundError("not_found", "revision was not found"))
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	tests := []struct {
+		version    string
+		statusCode int
+	}{
+		{"1", http.StatusOK},
+		{"5", http.StatusNotFound},
+		{"first", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages/my-id/revisions/"+tt.version, nil)
+		req.SetBasicAuth("test", "testpasswd")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.statusCode, w.Code, tt.version)
+	}
+}
+
+func TestMessageBoardHandler_Revert(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{
+			ID:      "my-id",
+			Name:    "Guilherme",
+			Email:   "xguiga@gmail.com",
+			Text:    "My text was updated",
+			Version: 2,
+		}, nil)
+	svc.EXPECT().
+		Revision(gomock.Any(), "my-id", int64(1)).
+		Return(&messageboard.Revision{
+			MessageID: "my-id",
+			Version:   1,
+			Name:      "Guilherme",
+			Email:     "xguiga@gmail.com",
+			Text:      "My text goes here",
+		}, nil)
+	svc.EXPECT().
+		Update(gomock.Any(), &messageboard.Message{
+			ID:      "my-id",
+			Name:    "Guilherme",
+			Email:   "xguiga@gmail.com",
+			Text:    "My text goes here",
+			Version: 2,
+		}).
+		Return(&messageboard.Message{
+			ID:      "my-id",
+			Name:    "Guilherme",
+			Email:   "xguiga@gmail.com",
+			Text:    "My text goes here",
+			Version: 3,
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages/my-id/revisions/1/revert", nil)
+	req.Header.Set("If-Match", `"2"`)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	assert.JSONEq(t, `{
+		"id": "my-id",
+		"name": "Guilherme",
+		"email": "xguiga@gmail.com",
+		"text": "My text goes here",
+		"creation_time": "0001-01-01T00:00:00Z",
+		"version": 3
 	}`, w.Body.String())
 }
+
+func TestMessageBoardHandler_Patch(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/merge-patch+json", `{"text": "My text was updated"}`},
+		{"application/json-patch+json; charset=utf-8", `[{"op": "replace", "path": "/text", "value": "My text was updated"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mock.NewService(ctrl)
+			svc.EXPECT().
+				Get(gomock.Any(), "my-id").
+				Return(&messageboard.Message{
+					ID:           "my-id",
+					Name:         "Guilherme",
+					Email:        "xguiga@gmail.com",
+					Text:         "My text goes here",
+					CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+					Version:      2,
+				}, nil)
+			// The version is always checked, the patch was applied to it.
+			svc.EXPECT().
+				Update(gomock.Any(), &messageboard.Message{
+					ID:      "my-id",
+					Name:    "Guilherme",
+					Email:   "xguiga@gmail.com",
+					Text:    "My text was updated",
+					Version: 2,
+				}).
+				Return(&messageboard.Message{
+					ID:           "my-id",
+					Name:         "Guilherme",
+					Email:        "xguiga@gmail.com",
+					Text:         "My text was updated",
+					CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+					Version:      3,
+				}, nil)
+
+			router := chi.NewRouter()
+			mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPatch, "http://localhost/v1/messages/my-id", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.SetBasicAuth("test", "testpasswd")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+			assert.JSONEq(t, `{
+				"id": "my-id",
+				"name": "Guilherme",
+				"email": "xguiga@gmail.com",
+				"text": "My text was updated",
+				"creation_time": "2020-08-12T15:30:00Z",
+				"version": 3
+			}`, w.Body.String())
+		})
+	}
+}
+
+func TestMessageBoardHandler_PatchTags(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		text        string
+		tags        []string
+	}{
+		{"application/merge-patch+json", `{"text": "My text was updated"}`, "My text was updated", []string{"x", "y"}},
+		{"application/merge-patch+json", `{"tags": ["z"]}`, "My text goes here", []string{"z"}},
+		{"application/json-patch+json", `[{"op": "add", "path": "/tags/-", "value": "z"}]`, "My text goes here", []string{"x", "y", "z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mock.NewService(ctrl)
+			svc.EXPECT().
+				Get(gomock.Any(), "my-id").
+				Return(&messageboard.Message{
+					ID:      "my-id",
+					Name:    "Guilherme",
+					Email:   "xguiga@gmail.com",
+					Text:    "My text goes here",
+					Tags:    []string{"x", "y"},
+					Version: 2,
+				}, nil)
+			svc.EXPECT().
+				Update(gomock.Any(), &messageboard.Message{
+					ID:      "my-id",
+					Name:    "Guilherme",
+					Email:   "xguiga@gmail.com",
+					Text:    tt.text,
+					Tags:    tt.tags,
+					Version: 2,
+				}).
+				Return(&messageboard.Message{ID: "my-id", Version: 3}, nil)
+
+			router := chi.NewRouter()
+			mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPatch, "http://localhost/v1/messages/my-id", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.SetBasicAuth("test", "testpasswd")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestMessageBoardHandler_PatchInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		statusCode  int
+		code        string
+	}{
+		{"unsupported", "application/json", `{"text": "My text was updated"}`, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"invalid patch", "application/merge-patch+json", `{"text": 1}`, http.StatusBadRequest, "invalid_patch"},
+		{"test failed", "application/json-patch+json", `[{"op": "test", "path": "/version", "value": 1}]`, http.StatusConflict, "patch_test_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mock.NewService(ctrl)
+			svc.EXPECT().
+				Get(gomock.Any(), "my-id").
+				Return(&messageboard.Message{ID: "my-id", Version: 2}, nil)
+
+			router := chi.NewRouter()
+			mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPatch, "http://localhost/v1/messages/my-id", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.SetBasicAuth("test", "testpasswd")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+			var mberr messageboard.Error
+			err := json.NewDecoder(w.Body).Decode(&mberr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.code, mberr.Code)
+			if tt.statusCode == http.StatusUnsupportedMediaType {
+				assert.Equal(t, "application/merge-patch+json, application/json-patch+json", w.Header().Get("Accept-Patch"))
+			}
+		})
+	}
+}
+
+func TestMessageBoardHandler_UpdateNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(nil, messageboard.NewError("not_found", "message not found"))
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost/v1/messages/my-id", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{
+		"code": "not_found",
+		"message": "message not found"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{
+			ID:           "my-id",
+			Name:         "Guilherme",
+			Email:        "xguiga@gmail.com",
+			Text:         "My text goes here",
+			CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		}, nil)
+	svc.EXPECT().
+		Delete(gomock.Any(), "my-id").
+		Return(nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "http://localhost/v1/messages/my-id", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestMessageBoardHandler_DeleteUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "http://localhost/v1/messages/my-id", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestMessageBoardHandler_ListTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{
+			BoardID: messageboard.DefaultBoardID,
+			PerPage: messageboard.DefaultPerPage,
+			Page:    1,
+			Tags:    [][]string{{"bug", "crash"}, {"urgent"}},
+		}).
+		Return(&messageboard.MessageList{
+			Total: 1,
+			Data: []*messageboard.Message{
+				{
+					ID:           "my-id",
+					Name:         "Guilherme",
+					Email:        "xguiga@gmail.com",
+					Text:         "My text goes here",
+					Tags:         []string{"bug", "urgent"},
+					CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+				},
+			},
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages?tag=Bug,crash&tag=urgent", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"total": 1,
+		"data": [
+			{
+				"id": "my-id",
+				"name": "Guilherme",
+				"email": "xguiga@gmail.com",
+				"text": "My text goes here",
+				"tags": ["bug", "urgent"],
+				"creation_time": "2020-08-12T15:30:00Z"
+			}
+		]
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_Tags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), "golang").
+		Return(&messageboard.Board{ID: "golang"}, nil)
+	svc.EXPECT().
+		Tags(gomock.Any(), "golang").
+		Return(&messageboard.TagList{
+			Total: 2,
+			Data: []*messageboard.TagCount{
+				{Tag: "bug", Count: 3},
+				{Tag: "urgent", Count: 1},
+			},
+		}, nil)
+	svc.EXPECT().
+		Tags(gomock.Any(), messageboard.DefaultBoardID).
+		Return(&messageboard.TagList{
+			Total: 0,
+			Data:  []*messageboard.TagCount{},
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/boards/golang/tags", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"total": 2,
+		"data": [
+			{"tag": "bug", "count": 3},
+			{"tag": "urgent", "count": 1}
+		]
+	}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost/v1/tags", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"total": 0, "data": []}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_Moderate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	msg := &messageboard.Message{
+		ID:           "my-id",
+		Name:         "Guilherme",
+		Email:        "xguiga@gmail.com",
+		Text:         "My text goes here",
+		CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		Status:       messageboard.StatusPending,
+	}
+	moderationTime := time.Date(2020, time.August, 13, 10, 0, 0, 0, time.UTC)
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(msg, nil).
+		Times(2)
+	svc.EXPECT().
+		Moderate(gomock.Any(), "my-id", &messageboard.Moderation{Status: messageboard.StatusApproved}).
+		Return(&messageboard.Message{ID: "my-id", Status: messageboard.StatusApproved}, nil)
+	svc.EXPECT().
+		Moderate(gomock.Any(), "my-id", &messageboard.Moderation{Status: messageboard.StatusRejected, Reason: "Spam"}).
+		Return(&messageboard.Message{
+			ID:             "my-id",
+			Name:           "Guilherme",
+			Email:          "xguiga@gmail.com",
+			Text:           "My text goes here",
+			CreationTime:   time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+			Status:         messageboard.StatusRejected,
+			StatusReason:   "Spam",
+			ModerationTime: &moderationTime,
+			ModeratedBy:    "test",
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	// The reason is optional when approving.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages/my-id/approve", http.NoBody)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The status in the body is ignored, it's defined by the endpoint.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost/v1/messages/my-id/reject", strings.NewReader(`{"status":"approved","reason":"Spam"}`))
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"id": "my-id",
+		"name": "Guilherme",
+		"email": "xguiga@gmail.com",
+		"text": "My text goes here",
+		"creation_time": "2020-08-12T15:30:00Z",
+		"status": "rejected",
+		"status_reason": "Spam",
+		"moderation_time": "2020-08-13T10:00:00Z",
+		"moderated_by": "test"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_ModerateUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages/my-id/approve", nil)
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestMessageBoardHandler_ListStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{
+			BoardID: messageboard.DefaultBoardID,
+			PerPage: messageboard.DefaultPerPage,
+			Page:    1,
+			Status:  []messageboard.Status{messageboard.StatusPending, messageboard.StatusFlagged},
+		}).
+		Return(&messageboard.MessageList{Data: []*messageboard.Message{}}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages?status=pending,flagged", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost/v1/messages?status=published", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"code": "invalid_status",
+		"message": "query string \"status\" must be pending, approved, rejected or flagged"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_ListTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	deletionTime := time.Date(2020, time.August, 13, 10, 0, 0, 0, time.UTC)
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{
+			BoardID: messageboard.DefaultBoardID,
+			PerPage: messageboard.DefaultPerPage,
+			Page:    1,
+			Deleted: true,
+		}).
+		Return(&messageboard.MessageList{
+			Total: 1,
+			Data: []*messageboard.Message{
+				{
+					ID:           "my-id",
+					Name:         "Guilherme",
+					Email:        "xguiga@gmail.com",
+					Text:         "My text goes here",
+					CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+					DeletionTime: &deletionTime,
+					DeletedBy:    "test",
+				},
+			},
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/trash", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"total": 1,
+		"data": [{
+			"id": "my-id",
+			"name": "Guilherme",
+			"email": "xguiga@gmail.com",
+			"text": "My text goes here",
+			"creation_time": "2020-08-12T15:30:00Z",
+			"deletion_time": "2020-08-13T10:00:00Z",
+			"deleted_by": "test"
+		}]
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_Restore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Restore(gomock.Any(), messageboard.DefaultBoardID, "my-id").
+		Return(&messageboard.Message{
+			ID:           "my-id",
+			Name:         "Guilherme",
+			Email:        "xguiga@gmail.com",
+			Text:         "My text goes here",
+			CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages/my-id/restore", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"id": "my-id",
+		"name": "Guilherme",
+		"email": "xguiga@gmail.com",
+		"text": "My text goes here",
+		"creation_time": "2020-08-12T15:30:00Z"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_ErrorStatusCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+		body       string
+	}{
+		{
+			name:       "validation",
+			err:        messageboard.NewValidationError("missing_name", `field "name" is missing`),
+			statusCode: http.StatusBadRequest,
+			body:       `{"code": "missing_name", "message": "field \"name\" is missing"}`,
+		},
+		{
+			name:       "conflict",
+			err:        messageboard.NewConflictError("conflict", "message was changed"),
+			statusCode: http.StatusConflict,
+			body:       `{"code": "conflict", "message": "message was changed"}`,
+		},
+		{
+			name:       "duplicate",
+			err:        messageboard.NewDuplicateError("other-id"),
+			statusCode: http.StatusConflict,
+			body:       `{"code": "duplicate", "message": "message was already created", "id": "other-id"}`,
+		},
+		{
+			name:       "precondition",
+			err:        messageboard.NewPreconditionError("precondition_failed", "message was changed"),
+			statusCode: http.StatusPreconditionFailed,
+			body:       `{"code": "precondition_failed", "message": "message was changed"}`,
+		},
+		{
+			name:       "not found",
+			err:        messageboard.NewNotFoundError("not_found", "message was not found"),
+			statusCode: http.StatusNotFound,
+			body:       `{"code": "not_found", "message": "message was not found"}`,
+		},
+		{
+			name:       "unauthorized",
+			err:        messageboard.NewUnauthorizedError("unauthorized", "user is not authorized"),
+			statusCode: http.StatusUnauthorized,
+			body:       `{"code": "unauthorized", "message": "user is not authorized"}`,
+		},
+		{
+			name:       "forbidden",
+			err:        messageboard.NewForbiddenError("forbidden", "user has no permission"),
+			statusCode: http.StatusForbidden,
+			body:       `{"code": "forbidden", "message": "user has no permission"}`,
+		},
+		{
+			name:       "rate limited",
+			err:        messageboard.NewRateLimitedError("rate_limited", "too many requests"),
+			statusCode: http.StatusTooManyRequests,
+			body:       `{"code": "rate_limited", "message": "too many requests"}`,
+		},
+		{
+			name:       "internal",
+			err:        messageboard.NewInternalError("internal", "something went wrong"),
+			statusCode: http.StatusInternalServerError,
+			body:       `{"code": "internal", "message": "something went wrong"}`,
+		},
+		{
+			name:       "uncategorized",
+			err:        messageboard.NewError("my_code", "something went wrong"),
+			statusCode: http.StatusInternalServerError,
+			body:       `{"code": "my_code", "message": "something went wrong"}`,
+		},
+		{
+			name:       "unknown",
+			err:        errors.New("something went wrong"),
+			statusCode: http.StatusInternalServerError,
+			body:       `{"code": "unknown_error", "message": "something went wrong"}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mock.NewService(ctrl)
+			svc.EXPECT().
+				Get(gomock.Any(), "my-id").
+				Return(nil, tt.err)
+
+			router := chi.NewRouter()
+			mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages/my-id", nil)
+			req.SetBasicAuth("test", "testpasswd")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.JSONEq(t, tt.body, w.Body.String())
+		})
+	}
+}
+
+func TestMessageBoardHandler_CreateInvalidJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	expectDefaultBoard(svc)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader("{"))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"code": "invalid_json",
+		"message": "unexpected EOF"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_CreateMerged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	expectDefaultBoard(svc)
+	svc.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&messageboard.Message{ID: "other-id", Merged: true}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(`{"name": "Guilherme", "email": "xguiga@gmail.com", "text": "My text goes here"}`))
+
+	router.ServeHTTP(w, req)
+
+	// Nothing was created.
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"id": "other-id",
+		"name": "",
+		"email": "",
+		"text": "",
+		"creation_time": "0001-01-01T00:00:00Z"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_UpdateNullBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{ID: "my-id", Version: 1}, nil)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost/v1/messages/my-id", strings.NewReader("null"))
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"code": "invalid_json",
+		"message": "body must be a message"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_CreateNullBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), messageboard.DefaultBoardID).
+		Return(messageboard.DefaultBoard(), nil).
+		Times(2)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	for _, url := range []string{
+		"http://localhost/v1/messages",
+		"http://localhost/v1/messages/my-id/replies",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader("null"))
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+		assert.JSONEq(t, `{
+			"code": "invalid_json",
+			"message": "body must be a message"
+		}`, w.Body.String(), url)
+	}
+}
+
+func TestMessageBoardHandler_CreateInvalidFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqMsg := &messageboard.Message{
+		BoardID: messageboard.DefaultBoardID,
+		Email:   "xguiga",
+		Text:    "My text goes here",
+	}
+
+	var errs messageboard.FieldErrors
+	errs.Add("name", "missing_name", `field "name" is missing`)
+	errs.Add("email", "invalid_email", `field "email" is not a valid email address`)
+
+	svc := mock.NewService(ctrl)
+	expectDefaultBoard(svc)
+	svc.EXPECT().
+		Create(gomock.Any(), reqMsg).
+		Return(nil, errs.Err())
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(reqMsg)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages", &buf)
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"code": "invalid_fields",
+		"message": "one or more fields are invalid",
+		"fields": [
+			{"field": "name", "code": "missing_name", "message": "field \"name\" is missing"},
+			{"field": "email", "code": "invalid_email", "message": "field \"email\" is not a valid email address"}
+		]
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_ErrorProblemJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []mbhttp.HandlerOption
+		accept  string
+		problem bool
+	}{
+		{name: "default format", accept: "", problem: false},
+		{name: "ask for problem", accept: "application/problem+json", problem: true},
+		{name: "prefer problem", accept: "application/json;q=0.5, application/problem+json", problem: true},
+		{name: "prefer json", accept: "application/json, application/problem+json;q=0.5", problem: false},
+		{name: "any", accept: "*/*", problem: false},
+		{
+			name:    "problem format",
+			opts:    []mbhttp.HandlerOption{mbhttp.WithErrorFormat(mbhttp.ErrorFormatProblem)},
+			accept:  "",
+			problem: true,
+		},
+		{
+			name:    "problem format asking for json",
+			opts:    []mbhttp.HandlerOption{mbhttp.WithErrorFormat(mbhttp.ErrorFormatProblem)},
+			accept:  "application/json",
+			problem: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mock.NewService(ctrl)
+			svc.EXPECT().
+				Get(gomock.Any(), "my-id").
+				Return(nil, messageboard.NewNotFoundError("not_found", "message was not found"))
+
+			router := chi.NewRouter()
+			mbhttp.NewMessageBoardHandler(router, svc, credentials, tt.opts...)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages/my-id", nil)
+			req.SetBasicAuth("test", "testpasswd")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			if tt.problem {
+				assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get("Content-Type"))
+				assert.JSONEq(t, `{
+					"type": "about:blank",
+					"title": "Not Found",
+					"status": 404,
+					"detail": "message was not found",
+					"instance": "/v1/messages/my-id",
+					"code": "not_found"
+				}`, w.Body.String())
+			} else {
+				assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+				assert.JSONEq(t, `{
+					"code": "not_found",
+					"message": "message was not found"
+				}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMessageBoardHandler_ErrorProblemJSONFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var errs messageboard.FieldErrors
+	errs.Add("name", "missing_name", "field name is missing")
+
+	svc := mock.NewService(ctrl)
+	expectDefaultBoard(svc)
+	svc.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(nil, errs.Err())
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials, mbhttp.WithErrorFormat(mbhttp.ErrorFormatProblem))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(`{"email":"xguiga@gmail.com"}`))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "one or more fields are invalid",
+		"instance": "/v1/messages",
+		"code": "invalid_fields",
+		"fields": [
+			{"field": "name", "code": "missing_name", "message": "field name is missing"}
+		]
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_UnauthorizedProblemJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Unauthorized",
+		"status": 401,
+		"detail": "user is not authorized to access this resource",
+		"instance": "/v1/messages",
+		"code": "unauthorized"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_ListCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cursor := &messageboard.Cursor{
+		Sort:         "-creation_time",
+		CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		ID:           "my-id",
+	}
+	msg := &messageboard.Message{
+		ID:           "other-id",
+		Name:         "Guilherme",
+		Email:        "xguiga@gmail.com",
+		Text:         "My text goes here",
+		CreationTime: time.Date(2020, time.August, 11, 15, 30, 0, 0, time.UTC),
+	}
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{
+			BoardID: messageboard.DefaultBoardID,
+			PerPage: 1,
+			Page:    1,
+			Cursor:  cursor,
+		}).
+		DoAndReturn(func(_ context.Context, _ *messageboard.ListOptions) (*messageboard.MessageList, error) {
+			list := &messageboard.MessageList{
+				Total: 3,
+				Data:  []*messageboard.Message{msg},
+			}
+			list.SetCursors(messageboard.DefaultSort, true, true)
+			return list, nil
+		})
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages?per_page=1&cursor="+cursor.String(), nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var list messageboard.MessageList
+	err := json.NewDecoder(w.Body).Decode(&list)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), list.Total)
+	assert.Equal(t, messageboard.NewCursor(msg, messageboard.DefaultSort, false).String(), list.Next)
+	assert.Equal(t, messageboard.NewCursor(msg, messageboard.DefaultSort, true).String(), list.Prev)
+}
+
+func TestMessageBoardHandler_ListInvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages?cursor=invalid", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"code": "invalid_cursor",
+		"message": "cursor is invalid"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_ListSort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{
+			BoardID: messageboard.DefaultBoardID,
+			PerPage: messageboard.DefaultPerPage,
+			Page:    1,
+			Sort:    messageboard.Sort{{Field: "name"}, {Field: "creation_time", Desc: true}},
+		}).
+		Return(&messageboard.MessageList{Data: []*messageboard.Message{}}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages?sort=name,-creation_time", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMessageBoardHandler_ListInvalidSort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages?sort=text", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"code": "invalid_sort",
+		"message": "sort must be a comma separated list of unique fields (prefixed with - for descending order), allowed fields are: creation_time, email, name"
+	}`, w.Body.String())
+}
+
+func TestMessageBoardHandler_ListQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{
+			BoardID: messageboard.DefaultBoardID,
+			PerPage: messageboard.DefaultPerPage,
+			Page:    1,
+			Query:   "golang",
+		}).
+		Return(&messageboard.MessageList{
+			Total: 1,
+			Data: []*messageboard.Message{
+				{
+					ID:           "my-id",
+					Name:         "Guilherme",
+					Email:        "xguiga@gmail.com",
+					Text:         "I love golang",
+					CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+					Score:        1.5,
+					Highlights: map[string][]string{
+						"text": {"I love <em>golang</em>"},
+					},
+				},
+			},
+		}, nil)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages?q=golang", nil)
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"total": 1,
+		"data": [{
+			"id": "my-id",
+			"name": "Guilherme",
+			"email": "xguiga@gmail.com",
+			"text": "I love golang",
+			"creation_time": "2020-08-12T15:30:00Z",
+			"score": 1.5,
+			"highlights": {
+				"text": ["I love <em>golang</em>"]
+			}
+		}]
+	}`, w.Body.String())
+}
+
+// expectDefaultBoard expects the public routes to check the default board.
+func expectDefaultBoard(svc *mock.Service) {
+	svc.EXPECT().
+		GetBoard(gomock.Any(), messageboard.DefaultBoardID).
+		Return(messageboard.DefaultBoard(), nil)
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/guilherme-santos/messageboard"
+)
+
+// ErrorFormat defines how errors are written in the responses.
+type ErrorFormat int
+
+const (
+	// ErrorFormatJSON writes errors as {"code": "...", "message": "..."}, unless
+	// the client asks for application/problem+json.
+	ErrorFormatJSON ErrorFormat = iota
+	// ErrorFormatProblem writes errors as application/problem+json (RFC 7807),
+	// unless the client asks for application/json.
+	ErrorFormatProblem
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is a RFC 7807 problem details document, extended with the error code
+// and the field errors of messageboard.Error.
+type Problem struct {
+	Type     string                     `json:"type"`
+	Title    string                     `json:"title"`
+	Status   int                        `json:"status"`
+	Detail   string                     `json:"detail,omitempty"`
+	Instance string                     `json:"instance,omitempty"`
+	Code     string                     `json:"code"`
+	Fields   []*messageboard.FieldError `json:"fields,omitempty"`
+	ID       string                     `json:"id,omitempty"`
+}
+
+func newProblem(req *http.Request, statusCode int, mberr *messageboard.Error) *Problem {
+	return &Problem{
+		// We don't have a documentation per error, so as RFC 7807 recommends,
+		// we use about:blank and the title is the status code description.
+		Type:     "about:blank",
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   mberr.Message,
+		Instance: req.URL.RequestURI(),
+		Code:     mberr.Code,
+		Fields:   mberr.Fields,
+		ID:       mberr.ID,
+	}
+}
+
+var errorFormatCtxKey = contextKey("error_format")
+
+// errorFormat is a middleware that saves the default error format in the
+// context, so it's available to responseError.
+func errorFormat(format ErrorFormat) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), errorFormatCtxKey, format)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// wantsProblem negotiates, using the Accept header, if the error should be
+// written as application/problem+json.
+func wantsProblem(req *http.Request) bool {
+	format, _ := req.Context().Value(errorFormatCtxKey).(ErrorFormat)
+	problemQ, jsonQ := acceptQuality(req.Header.Get("Accept"))
+
+	if format == ErrorFormatProblem {
+		return jsonQ <= 0 || problemQ >= jsonQ
+	}
+	return problemQ > 0 && problemQ >= jsonQ
+}
+
+// acceptQuality returns the quality of application/problem+json and application/json
+// found in the Accept header, or -1 if they are not explicitly present.
+func acceptQuality(accept string) (problemQ, jsonQ float64) {
+	problemQ, jsonQ = -1, -1
+	if accept == "" {
+		return
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case problemContentType:
+			problemQ = q
+		case "application/json":
+			jsonQ = q
+		}
+	}
+	return
+}
+
+// responseProblem responds the http call with the status code and the problem as json.
+func responseProblem(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", problemContentType+"; charset=utf-8")
+	w.WriteHeader(problem.Status)
+	err := json.NewEncoder(w).Encode(problem)
+	if err != nil {
+		log.Println("unable to encoding response as json:", err)
+	}
+}
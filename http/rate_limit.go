@@ -0,0 +1,187 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+)
+
+// RateLimit is the state of the limit of a client after a request.
+type RateLimit struct {
+	// Allowed is false when the client has to wait before trying again.
+	Allowed bool
+	// Limit is how many requests the client can do in a row.
+	Limit int
+	// Remaining is how many requests the client can still do right now.
+	Remaining int
+	// RetryAfter is how long the client has to wait for the next request, when it's not allowed.
+	RetryAfter time.Duration
+	// Reset is when the client will have Limit requests again.
+	Reset time.Time
+}
+
+// RateLimiter limits how many requests each client, identified by key, can do.
+type RateLimiter interface {
+	Allow(key string) RateLimit
+}
+
+// RateLimitKey returns the key identifying the client of the request.
+type RateLimitKey func(*http.Request) string
+
+// RateLimitByIP identifies the clients by their ip address.
+func RateLimitByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateLimitByEmail identifies the clients by the email of the message being
+// created, and by the ip address when the body has no email or it's bigger
+// than MaxBodySize.
+func RateLimitByEmail(r *http.Request) string {
+	body := http.MaxBytesReader(nil, r.Body, MaxBodySize)
+	b, err := ioutil.ReadAll(body)
+	// The body still needs to be read by the handler, which also gets the
+	// error of bodies too big.
+	r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(b), body))
+	if err != nil {
+		return RateLimitByIP(r)
+	}
+
+	var msg struct {
+		Email string `json:"email"`
+	}
+	json.Unmarshal(b, &msg)
+	if email := strings.ToLower(strings.TrimSpace(msg.Email)); email != "" {
+		return email
+	}
+	return RateLimitByIP(r)
+}
+
+// TokenBucket is an in-process RateLimiter, each client has a bucket with up to
+// burst tokens, which is refilled with one token each interval.
+type TokenBucket struct {
+	burst    int
+	interval time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a TokenBucket allowing burst requests in a row, and
+// one more request each interval.
+func NewTokenBucket(burst int, interval time.Duration) *TokenBucket {
+	return &TokenBucket{
+		burst:     burst,
+		interval:  interval,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow implements RateLimiter.
+func (tb *TokenBucket) Allow(key string) RateLimit {
+	now := time.Now()
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.sweep(now)
+
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(tb.burst), last: now}
+		tb.buckets[key] = b
+	}
+	b.tokens = tb.refill(b, now)
+	b.last = now
+
+	limit := RateLimit{Limit: tb.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		limit.Allowed = true
+	} else {
+		limit.RetryAfter = time.Duration((1 - b.tokens) * float64(tb.interval))
+	}
+	limit.Remaining = int(b.tokens)
+	limit.Reset = now.Add(time.Duration((float64(tb.burst) - b.tokens) * float64(tb.interval)))
+	return limit
+}
+
+// refill returns the tokens of b at now.
+func (tb *TokenBucket) refill(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + float64(now.Sub(b.last))/float64(tb.interval)
+	return math.Min(tokens, float64(tb.burst))
+}
+
+// sweep removes the full buckets from time to time, they are the same as
+// buckets not created yet, this way the memory doesn't grow forever.
+func (tb *TokenBucket) sweep(now time.Time) {
+	full := time.Duration(tb.burst) * tb.interval
+	if now.Sub(tb.lastSweep) < full {
+		return
+	}
+	for key, b := range tb.buckets {
+		if tb.refill(b, now) >= float64(tb.burst) {
+			delete(tb.buckets, key)
+		}
+	}
+	tb.lastSweep = now
+}
+
+// WithRateLimiter limits the requests creating messages, which are public, to
+// avoid a single client flooding the board. The clients are identified by key
+// inside of each tenant, by their ip address when key is nil. By default there
+// is no limit.
+func WithRateLimiter(limiter RateLimiter, key RateLimitKey) HandlerOption {
+	if key == nil {
+		key = RateLimitByIP
+	}
+	return func(h *MessageBoardHandler) {
+		h.rateLimiter = limiter
+		h.rateLimitKey = key
+	}
+}
+
+// rateLimit responds 429 to the clients above their rate limit.
+func (h *MessageBoardHandler) rateLimit(next http.Handler) http.Handler {
+	if h.rateLimiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tenant := messageboard.TenantFromContext(req.Context())
+		limit := h.rateLimiter.Allow(tenant + "/" + h.rateLimitKey(req))
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(limit.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(limit.Reset.Unix(), 10))
+
+		if !limit.Allowed {
+			retryAfter := int(math.Ceil(limit.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			responseError(w, req, messageboard.NewRateLimitedError("rate_limited",
+				fmt.Sprintf("too many messages, try again in %d seconds", retryAfter)))
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
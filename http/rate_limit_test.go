@@ -0,0 +1,115 @@
+package http_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+	mbhttp "github.com/guilherme-santos/messageboard/http"
+	"github.com/guilherme-santos/messageboard/mock"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	tb := mbhttp.NewTokenBucket(2, 20*time.Millisecond)
+
+	limit := tb.Allow("client")
+	assert.True(t, limit.Allowed)
+	assert.Equal(t, 2, limit.Limit)
+	assert.Equal(t, 1, limit.Remaining)
+
+	limit = tb.Allow("client")
+	assert.True(t, limit.Allowed)
+	assert.Equal(t, 0, limit.Remaining)
+
+	limit = tb.Allow("client")
+	assert.False(t, limit.Allowed)
+	assert.Equal(t, 0, limit.Remaining)
+	assert.True(t, limit.RetryAfter > 0 && limit.RetryAfter <= 20*time.Millisecond, "unexpected retry after: %s", limit.RetryAfter)
+
+	// Other clients have their own bucket.
+	assert.True(t, tb.Allow("other").Allowed)
+
+	time.Sleep(limit.RetryAfter)
+	assert.True(t, tb.Allow("client").Allowed)
+}
+
+func TestMessageBoardHandler_RateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	svc.EXPECT().
+		GetBoard(gomock.Any(), messageboard.DefaultBoardID).
+		Return(messageboard.DefaultBoard(), nil).
+		Times(2)
+	svc.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(&messageboard.Message{ID: "my-id"}, nil).
+		Times(2)
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials,
+		mbhttp.WithRateLimiter(mbhttp.NewTokenBucket(1, time.Minute), mbhttp.RateLimitByEmail),
+	)
+
+	create := func(email string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(`{
+			"name": "Guilherme",
+			"email": "`+email+`",
+			"text": "My text goes here"
+		}`))
+
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := create("xguiga@gmail.com")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = create("XGuiga@gmail.com")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	reset, _ := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+	assert.InDelta(t, time.Now().Add(time.Minute).Unix(), reset, 2)
+	assert.JSONEq(t, `{
+		"code": "rate_limited",
+		"message": "too many messages, try again in 60 seconds"
+	}`, w.Body.String())
+
+	w = create("other@gmail.com")
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestMessageBoardHandler_RateLimitByIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/v1/messages", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	assert.Equal(t, "10.0.0.1", mbhttp.RateLimitByIP(req))
+
+	// Without email in the body, the ip is used.
+	req = httptest.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(`{"name": "Guilherme"}`))
+	req.RemoteAddr = "10.0.0.1:12345"
+	assert.Equal(t, "10.0.0.1", mbhttp.RateLimitByEmail(req))
+
+	// Bodies too big are not read, the handler fails reading them too.
+	big := `{"email": "xguiga@gmail.com", "text": "` + strings.Repeat("a", mbhttp.MaxBodySize) + `"}`
+	req = httptest.NewRequest(http.MethodPost, "http://localhost/v1/messages", strings.NewReader(big))
+	req.RemoteAddr = "10.0.0.1:12345"
+	assert.Equal(t, "10.0.0.1", mbhttp.RateLimitByEmail(req))
+	_, err := ioutil.ReadAll(req.Body)
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/guilherme-santos/messageboard"
+)
+
+// TenantResolver returns the tenant of the request, or an empty string if the
+// request doesn't have one.
+type TenantResolver func(*http.Request) string
+
+// TenantFromHeader resolves the tenant from the header name, e.g. X-Tenant-ID.
+func TenantFromHeader(name string) TenantResolver {
+	return func(r *http.Request) string {
+		return strings.TrimSpace(r.Header.Get(name))
+	}
+}
+
+// TenantFromSubdomain resolves the tenant from the subdomain of domain, e.g. the
+// tenant of acme.board.example.com is acme when domain is board.example.com.
+func TenantFromSubdomain(domain string) TenantResolver {
+	suffix := "." + strings.ToLower(domain)
+	return func(r *http.Request) string {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(host)
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		return strings.TrimSuffix(host, suffix)
+	}
+}
+
+// TenantFromCredentials resolves the tenant from the user of the basic auth,
+// tenants maps the users to their tenant. Users without tenant belong to the
+// default one, so they can't reach other tenants through the header or the
+// subdomain. Wrong passwords don't resolve any tenant.
+func TenantFromCredentials(creds, tenants map[string]string) TenantResolver {
+	return func(r *http.Request) string {
+		user, pass, ok := r.BasicAuth()
+		if !ok {
+			return ""
+		}
+		credPass, credUserOk := creds[user]
+		if !credUserOk || pass != credPass {
+			return ""
+		}
+		if tenant, ok := tenants[user]; ok {
+			return tenant
+		}
+		return messageboard.DefaultTenant
+	}
+}
+
+// WithTenantResolvers sets how the tenant of the requests is resolved. Requests
+// resolved to different tenants, e.g. an user of a tenant asking for another
+// one in the header, are forbidden, and requests without tenant use the default one.
+// Only the default tenant and the ones set by WithKnownTenants are accepted.
+func WithTenantResolvers(resolvers ...TenantResolver) HandlerOption {
+	return func(h *MessageBoardHandler) {
+		h.tenantResolvers = resolvers
+	}
+}
+
+// WithKnownTenants sets the tenants the requests can be resolved to, besides
+// the default one. The storages create the data of a tenant the first time it's
+// used, so requests to other tenants fail before reaching them.
+func WithKnownTenants(tenants ...string) HandlerOption {
+	return func(h *MessageBoardHandler) {
+		h.knownTenants = make(map[string]bool, len(tenants))
+		for _, t := range tenants {
+			h.knownTenants[t] = true
+		}
+	}
+}
+
+// resolveTenant saves the tenant of the request in the context, so the storages
+// only reach its data.
+func resolveTenant(resolvers []TenantResolver, known map[string]bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var tenant string
+			for _, resolve := range resolvers {
+				t := resolve(r)
+				if t == "" {
+					continue
+				}
+				if tenant != "" && t != tenant {
+					responseError(w, r, messageboard.NewForbiddenError("tenant_mismatch", "request was resolved to more than one tenant"))
+					return
+				}
+				tenant = t
+			}
+			if tenant == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !messageboard.ValidTenant(tenant) {
+				responseError(w, r, messageboard.ErrInvalidTenant)
+				return
+			}
+			if tenant != messageboard.DefaultTenant && !known[tenant] {
+				responseError(w, r, messageboard.NewNotFoundError("tenant_not_found", "tenant was not found"))
+				return
+			}
+
+			ctx := messageboard.ContextWithTenant(r.Context(), tenant)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
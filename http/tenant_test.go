@@ -0,0 +1,202 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guilherme-santos/messageboard"
+	mbhttp "github.com/guilherme-santos/messageboard/http"
+	"github.com/guilherme-santos/messageboard/memory"
+	"github.com/guilherme-santos/messageboard/mock"
+
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageBoardHandler_Tenant(t *testing.T) {
+	resolvers := mbhttp.WithTenantResolvers(
+		mbhttp.TenantFromCredentials(credentials, map[string]string{"test": "acme"}),
+		mbhttp.TenantFromHeader("X-Tenant-ID"),
+		mbhttp.TenantFromSubdomain("board.example.com"),
+	)
+
+	tests := []struct {
+		name   string
+		host   string
+		header string
+		auth   bool
+		tenant string
+	}{
+		{name: "no tenant", host: "localhost", tenant: messageboard.DefaultTenant},
+		{name: "header", host: "localhost", header: "globex", tenant: "globex"},
+		{name: "subdomain", host: "globex.board.example.com:8080", tenant: "globex"},
+		{name: "credentials", host: "localhost", auth: true, tenant: "acme"},
+		{name: "all agree", host: "acme.board.example.com", header: "acme", auth: true, tenant: "acme"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := mock.NewService(ctrl)
+			svc.EXPECT().
+				GetBoard(gomock.Any(), messageboard.DefaultBoardID).
+				DoAndReturn(func(ctx context.Context, id string) (*messageboard.Board, error) {
+					assert.Equal(t, tt.tenant, messageboard.TenantFromContext(ctx))
+					return messageboard.DefaultBoard(), nil
+				})
+			svc.EXPECT().
+				Create(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, msg *messageboard.Message) (*messageboard.Message, error) {
+					assert.Equal(t, tt.tenant, messageboard.TenantFromContext(ctx))
+					return msg, nil
+				})
+
+			router := chi.NewRouter()
+			mbhttp.NewMessageBoardHandler(router, svc, credentials, resolvers, mbhttp.WithKnownTenants("acme", "globex"))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "http://"+tt.host+"/v1/messages", strings.NewReader(`{}`))
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			if tt.auth {
+				req.SetBasicAuth("test", "testpasswd")
+			}
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+		})
+	}
+}
+
+func TestMessageBoardHandler_TenantInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials,
+		mbhttp.WithTenantResolvers(
+			mbhttp.TenantFromCredentials(credentials, map[string]string{"test": "acme"}),
+			mbhttp.TenantFromHeader("X-Tenant-ID"),
+		),
+		mbhttp.WithKnownTenants("acme", "globex"),
+	)
+
+	tests := []struct {
+		name   string
+		header string
+		code   int
+		body   string
+	}{
+		{
+			name:   "mismatch",
+			header: "globex",
+			code:   http.StatusForbidden,
+			body:   `{"code": "tenant_mismatch", "message": "request was resolved to more than one tenant"}`,
+		},
+		{
+			name:   "invalid",
+			header: "../acme",
+			code:   http.StatusBadRequest,
+			body:   `{"code": "invalid_tenant", "message": "tenant must have only lowercase letters, digits and \"-\", starting with a letter or digit"}`,
+		},
+		{
+			name:   "unknown",
+			header: "initech",
+			code:   http.StatusNotFound,
+			body:   `{"code": "tenant_not_found", "message": "tenant was not found"}`,
+		},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages", nil)
+		req.Header.Set("X-Tenant-ID", tt.header)
+		if tt.name == "mismatch" {
+			req.SetBasicAuth("test", "testpasswd")
+		}
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.code, w.Code, tt.name)
+		assert.JSONEq(t, tt.body, w.Body.String(), tt.name)
+	}
+}
+
+func TestMessageBoardHandler_TenantUnmappedUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mock.NewService(ctrl)
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials,
+		mbhttp.WithTenantResolvers(
+			mbhttp.TenantFromCredentials(credentials, map[string]string{"other": "acme"}),
+			mbhttp.TenantFromHeader("X-Tenant-ID"),
+		),
+		mbhttp.WithKnownTenants("acme"),
+	)
+
+	// Users without tenant are in the default one, they can't choose another.
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/v1/messages", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	req.SetBasicAuth("test", "testpasswd")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"code": "tenant_mismatch", "message": "request was resolved to more than one tenant"}`, w.Body.String())
+}
+
+// TestMessageBoardHandler_TenantIsolation checks, end to end, that a message
+// created in a tenant is not reachable from the others.
+func TestMessageBoardHandler_TenantIsolation(t *testing.T) {
+	svc := messageboard.NewService(memory.NewMessageBoardStorage())
+
+	router := chi.NewRouter()
+	mbhttp.NewMessageBoardHandler(router, svc, credentials,
+		mbhttp.WithTenantResolvers(mbhttp.TenantFromHeader("X-Tenant-ID")),
+		mbhttp.WithKnownTenants("acme", "globex"),
+	)
+
+	do := func(method, url, tenant, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("X-Tenant-ID", tenant)
+		req.SetBasicAuth("test", "testpasswd")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "http://localhost/v1/messages", "acme", `{"name":"Guilherme","email":"xguiga@gmail.com","text":"My text goes here"}`)
+	if !assert.Equal(t, http.StatusCreated, w.Code) {
+		return
+	}
+	var msg *messageboard.Message
+	json.NewDecoder(w.Body).Decode(&msg)
+	id := msg.ID
+
+	w = do(http.MethodGet, "http://localhost/v1/messages/"+id, "acme", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, tenant := range []string{"globex", ""} {
+		w = do(http.MethodGet, "http://localhost/v1/messages/"+id, tenant, "")
+		assert.Equal(t, http.StatusNotFound, w.Code, tenant)
+
+		w = do(http.MethodGet, "http://localhost/v1/messages", tenant, "")
+		assert.Equal(t, http.StatusOK, w.Code, tenant)
+		assert.JSONEq(t, `{"total": 0, "data": []}`, w.Body.String(), tenant)
+
+		w = do(http.MethodDelete, "http://localhost/v1/messages/"+id, tenant, "")
+		assert.Equal(t, http.StatusNotFound, w.Code, tenant)
+	}
+}
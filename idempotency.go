@@ -0,0 +1,37 @@
+package messageboard
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyKey is a request made with an Idempotency-Key, which is safe to
+// retry, the response of the first request is returned to the following ones.
+type IdempotencyKey struct {
+	Key string `bson:"_id"`
+	// RequestHash identifies the request, the key can't be reused by other requests.
+	RequestHash string `bson:"request_hash"`
+	// StatusCode and Body are the response, they are only set when the request is completed.
+	StatusCode int    `bson:"status_code,omitempty"`
+	Body       []byte `bson:"body,omitempty"`
+	// ExpirationTime is when the key can be used again.
+	ExpirationTime time.Time `bson:"expiration_time"`
+}
+
+// Completed returns true if the response of the request is already known.
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
+
+//go:generate mockgen -package mock -mock_names IdempotencyStore=IdempotencyStore -destination mock/idempotency_store.go github.com/guilherme-santos/messageboard IdempotencyStore
+
+// IdempotencyStore keeps the idempotency keys of the requests.
+type IdempotencyStore interface {
+	// ReserveKey saves k, unless its key is already saved and not expired, in
+	// that case the saved one is returned.
+	ReserveKey(_ context.Context, k *IdempotencyKey) (*IdempotencyKey, error)
+	// CompleteKey saves the response of the request reserved with key.
+	CompleteKey(_ context.Context, key string, statusCode int, body []byte) error
+	// ReleaseKey removes key, e.g. when the request fails and it can be retried.
+	ReleaseKey(_ context.Context, key string) error
+}
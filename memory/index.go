@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"math"
+
+	"github.com/guilherme-santos/messageboard"
+)
+
+// nameWeight is how much a term in the name is worth compared to the text,
+// the same weight is used by the text index in mongodb.
+const nameWeight = 2
+
+// index is a small inverted index used by the full-text search, it's not safe
+// for concurrent use, MessageBoardStorage protects it with its own lock.
+type index struct {
+	// postings maps a term to its weighted frequency in each message.
+	postings map[string]map[string]float64
+	// terms maps a message to its terms, used to remove it from postings.
+	terms map[string][]string
+}
+
+func newIndex() *index {
+	return &index{
+		postings: make(map[string]map[string]float64),
+		terms:    make(map[string][]string),
+	}
+}
+
+// add indexes msg, replacing what was indexed before for it.
+func (idx *index) add(msg *messageboard.Message) {
+	idx.remove(msg.ID)
+
+	freqs := make(map[string]float64)
+	for _, term := range messageboard.Terms(msg.Name) {
+		freqs[term] += nameWeight
+	}
+	for _, term := range messageboard.Terms(msg.Text) {
+		freqs[term]++
+	}
+
+	terms := make([]string, 0, len(freqs))
+	for term, freq := range freqs {
+		postings, ok := idx.postings[term]
+		if !ok {
+			postings = make(map[string]float64)
+			idx.postings[term] = postings
+		}
+		postings[msg.ID] = freq
+		terms = append(terms, term)
+	}
+	idx.terms[msg.ID] = terms
+}
+
+// remove removes the message with the given id from the index.
+func (idx *index) remove(id string) {
+	for _, term := range idx.terms[id] {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.terms, id)
+}
+
+// search returns the score of every message matching at least one of the
+// terms in query, using tf-idf.
+func (idx *index) search(query string) map[string]float64 {
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, term := range messageboard.Terms(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + float64(len(idx.terms))/float64(len(postings)))
+		for id, freq := range postings {
+			scores[id] += freq * idf
+		}
+	}
+	return scores
+}
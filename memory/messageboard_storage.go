@@ -0,0 +1,686 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/google/uuid"
+)
+
+// MessageBoardStorage is an in-memory implementation of messageboard.Storage.
+//
+// It's safe for concurrent use and it's meant to run the service locally or in
+// integration tests without the need of a MongoDB instance. Each tenant has its
+// own data, taken from the context of each call.
+type MessageBoardStorage struct {
+	mu      sync.Mutex
+	tenants map[string]*tenantStorage
+}
+
+// tenantStorage has the data of a single tenant.
+type tenantStorage struct {
+	mu    sync.RWMutex
+	msgs  map[string]*messageboard.Message
+	index *index
+	// revisions has the revisions of each message, oldest first.
+	revisions map[string][]*messageboard.Revision
+	boards    map[string]*messageboard.Board
+	// idempotencyKeys are kept until they expire, see messageboard.IdempotencyStore.
+	idempotencyKeys map[string]*messageboard.IdempotencyKey
+	lastSweep       time.Time
+}
+
+func NewMessageBoardStorage() *MessageBoardStorage {
+	return &MessageBoardStorage{
+		tenants: make(map[string]*tenantStorage),
+	}
+}
+
+func newTenantStorage() *tenantStorage {
+	return &tenantStorage{
+		msgs:            make(map[string]*messageboard.Message),
+		index:           newIndex(),
+		revisions:       make(map[string][]*messageboard.Revision),
+		boards:          make(map[string]*messageboard.Board),
+		idempotencyKeys: make(map[string]*messageboard.IdempotencyKey),
+		lastSweep:       time.Now().UTC(),
+	}
+}
+
+// tenant returns the data of the tenant in ctx, there is no way to reach the
+// data of another tenant from it.
+func (s *MessageBoardStorage) tenant(ctx context.Context) (*tenantStorage, error) {
+	name := messageboard.TenantFromContext(ctx)
+	if !messageboard.ValidTenant(name) {
+		return nil, messageboard.ErrInvalidTenant
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tenants[name]
+	if !ok {
+		t = newTenantStorage()
+		s.tenants[name] = t
+	}
+	return t, nil
+}
+
+func (s *MessageBoardStorage) Create(ctx context.Context, msg *messageboard.Message) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msg.ID = uuid.New().String()
+	// MongoDB stores times with millisecond precision, we do the same to keep
+	// both implementations returning the same values.
+	msg.CreationTime = time.Now().UTC().Truncate(time.Millisecond)
+	msg.Version = 1
+	msg.ReplyCount = 0
+	// The fields below are only set by other operations, never on creation.
+	msg.UpdateTime = nil
+	msg.UpdatedBy = ""
+	msg.DeletionTime = nil
+	msg.DeletedBy = ""
+	msg.ModerationTime = nil
+	msg.ModeratedBy = ""
+	msg.Score = 0
+	msg.Highlights = nil
+	msg.Replies = nil
+	msg.ContentHash = messageboard.ContentHash(msg)
+	t.msgs[msg.ID] = clone(msg)
+	t.index.add(msg)
+	t.addReplies(msg.ParentID, 1)
+	return nil
+}
+
+func (s *MessageBoardStorage) List(ctx context.Context, opts *messageboard.ListOptions) (*messageboard.MessageList, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	err = opts.CheckCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	var scores map[string]float64
+	if opts.Query != "" {
+		scores = t.index.search(opts.Query)
+	}
+
+	all := make([]*messageboard.Message, 0, len(t.msgs))
+	for _, msg := range t.msgs {
+		if scores != nil && scores[msg.ID] == 0 {
+			continue
+		}
+		if match(msg, opts) {
+			all = append(all, msg)
+		}
+	}
+	// When sorting by relevance, most relevant first, and messages with the same
+	// score are sorted by the default sort.
+	srt := opts.SortOrder()
+	byRelevance := opts.SortByRelevance()
+	sort.Slice(all, func(i, j int) bool {
+		if byRelevance && scores[all[i].ID] != scores[all[j].ID] {
+			return scores[all[i].ID] > scores[all[j].ID]
+		}
+		return srt.Compare(all[i], all[j]) < 0
+	})
+
+	list := &messageboard.MessageList{
+		Total: uint(len(all)),
+		Data:  make([]*messageboard.Message, 0),
+	}
+
+	start, end := pageBounds(all, srt, opts)
+	for _, msg := range all[start:end] {
+		msg = clone(msg)
+		msg.Score = scores[msg.ID]
+		list.Data = append(list.Data, msg)
+	}
+	if !byRelevance {
+		list.SetCursors(srt, start > 0, end < len(all))
+	}
+	return list, nil
+}
+
+// match returns true if msg matches all filters in opts.
+func match(msg *messageboard.Message, opts *messageboard.ListOptions) bool {
+	switch {
+	case msg.IsDeleted() != opts.Deleted:
+		return false
+	case opts.BoardID != "" && !msg.InBoard(opts.BoardID):
+		return false
+	case opts.ParentID != "" && msg.ParentID != opts.ParentID:
+		return false
+	case opts.Name != "" && msg.Name != opts.Name:
+		return false
+	case opts.Email != "" && msg.Email != opts.Email:
+		return false
+	case !msg.MatchTags(opts.Tags):
+		return false
+	case !msg.HasStatus(opts.Status):
+		return false
+	case !opts.CreatedAfter.IsZero() && !msg.CreationTime.After(opts.CreatedAfter):
+		return false
+	case !opts.CreatedBefore.IsZero() && !msg.CreationTime.Before(opts.CreatedBefore):
+		return false
+	}
+	return true
+}
+
+// pageBounds returns the bounds of the page requested in opts, all should be
+// already sorted by srt.
+func pageBounds(all []*messageboard.Message, srt messageboard.Sort, opts *messageboard.ListOptions) (start, end int) {
+	perPage := int(opts.PerPage)
+	if perPage == 0 {
+		perPage = len(all)
+	}
+
+	switch c := opts.Cursor; {
+	case c == nil:
+		page := int(opts.Page)
+		if page == 0 {
+			page = 1
+		}
+		start = perPage * (page - 1)
+		if start > len(all) {
+			start = len(all)
+		}
+		end = start + perPage
+	case c.Prev:
+		// Page ends right before the cursor position.
+		end = sort.Search(len(all), func(i int) bool {
+			return !c.IsBefore(all[i], srt)
+		})
+		start = end - perPage
+		if start < 0 {
+			start = 0
+		}
+	default:
+		// Page starts right after the cursor position.
+		start = sort.Search(len(all), func(i int) bool {
+			return c.IsAfter(all[i], srt)
+		})
+		end = start + perPage
+	}
+
+	if end > len(all) {
+		end = len(all)
+	}
+	return start, end
+}
+
+func (s *MessageBoardStorage) Get(ctx context.Context, id string) (*messageboard.Message, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	msg, ok := t.msgs[id]
+	if !ok || msg.IsDeleted() {
+		return nil, messageboard.NewNotFoundError("not_found", "message was not found")
+	}
+	return clone(msg), nil
+}
+
+func (s *MessageBoardStorage) Update(ctx context.Context, msg *messageboard.Message, updatedBy string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, ok := t.msgs[msg.ID]
+	if !ok || current.IsDeleted() {
+		return messageboard.NewNotFoundError("not_found", "message was not found")
+	}
+	if msg.Version != 0 && msg.Version != current.Version {
+		return errVersionConflict
+	}
+	t.revisions[msg.ID] = append(t.revisions[msg.ID], messageboard.NewRevision(current, updatedBy))
+	current.Name = msg.Name
+	current.Email = msg.Email
+	current.Text = msg.Text
+	current.Tags = append([]string(nil), msg.Tags...)
+	current.ContentHash = messageboard.ContentHash(current)
+	current.Version++
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	current.UpdateTime = &now
+	current.UpdatedBy = updatedBy
+	if msg.Status != "" {
+		current.Status = msg.Status
+		current.StatusReason = msg.StatusReason
+		current.ModerationTime = &now
+		current.ModeratedBy = ""
+	}
+	t.index.add(current)
+	return nil
+}
+
+func (s *MessageBoardStorage) Moderate(ctx context.Context, id string, m *messageboard.Moderation, moderatedBy string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msg, ok := t.msgs[id]
+	if !ok || msg.IsDeleted() {
+		return messageboard.NewNotFoundError("not_found", "message was not found")
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	msg.Status = m.Status
+	msg.StatusReason = m.Reason
+	msg.ModerationTime = &now
+	msg.ModeratedBy = moderatedBy
+	return nil
+}
+
+func (s *MessageBoardStorage) FindDuplicate(ctx context.Context, msg *messageboard.Message, since time.Time) (*messageboard.Message, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	hash := messageboard.ContentHash(msg)
+	boardID := msg.BoardID
+	if boardID == "" {
+		boardID = messageboard.DefaultBoardID
+	}
+
+	var dup *messageboard.Message
+	for _, m := range t.msgs {
+		if m.IsDeleted() || m.ContentHash != hash || !m.InBoard(boardID) || m.ParentID != msg.ParentID || m.CreationTime.Before(since) {
+			continue
+		}
+		if dup == nil || m.CreationTime.After(dup.CreationTime) {
+			dup = m
+		}
+	}
+	if dup == nil {
+		return nil, messageboard.NewNotFoundError("not_found", "message was not found")
+	}
+	return clone(dup), nil
+}
+
+var errVersionConflict = messageboard.NewConflictError("version_conflict", "message was updated by someone else, get its last version and try again")
+
+func (s *MessageBoardStorage) Delete(ctx context.Context, id, deletedBy string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msg, ok := t.msgs[id]
+	if !ok || msg.IsDeleted() {
+		return messageboard.NewNotFoundError("not_found", "message was not found")
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	msg.DeletionTime = &now
+	msg.DeletedBy = deletedBy
+	t.addReplies(msg.ParentID, -1)
+	return nil
+}
+
+func (s *MessageBoardStorage) Restore(ctx context.Context, boardID, id string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msg, ok := t.msgs[id]
+	if !ok || !msg.IsDeleted() || !msg.InBoard(boardID) {
+		return messageboard.NewNotFoundError("not_found", "message was not found in the trash")
+	}
+	msg.DeletionTime = nil
+	msg.DeletedBy = ""
+	t.addReplies(msg.ParentID, 1)
+	return nil
+}
+
+// addReplies adds n to the ReplyCount of the message parentID, if any.
+func (t *tenantStorage) addReplies(parentID string, n int64) {
+	if parent, ok := t.msgs[parentID]; ok {
+		parent.ReplyCount += n
+	}
+}
+
+func (s *MessageBoardStorage) Revisions(ctx context.Context, id string) (*messageboard.RevisionList, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	revs := t.revisions[id]
+	list := &messageboard.RevisionList{
+		Total: uint(len(revs)),
+		Data:  make([]*messageboard.Revision, 0, len(revs)),
+	}
+	for i := len(revs) - 1; i >= 0; i-- {
+		rev := *revs[i]
+		list.Data = append(list.Data, &rev)
+	}
+	return list, nil
+}
+
+func (s *MessageBoardStorage) Revision(ctx context.Context, id string, version int64) (*messageboard.Revision, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, rev := range t.revisions[id] {
+		if rev.Version == version {
+			rev := *rev
+			return &rev, nil
+		}
+	}
+	return nil, errRevisionNotFound
+}
+
+var errRevisionNotFound = messageboard.NewNotFoundError("not_found", "revision was not found")
+
+func (s *MessageBoardStorage) CreateBoard(ctx context.Context, b *messageboard.Board) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.boards[b.ID]; ok {
+		return messageboard.NewConflictError("board_already_exists", "board already exists")
+	}
+	b.CreationTime = time.Now().UTC().Truncate(time.Millisecond)
+	c := *b
+	t.boards[b.ID] = &c
+	return nil
+}
+
+func (s *MessageBoardStorage) ListBoards(ctx context.Context) (*messageboard.BoardList, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	list := &messageboard.BoardList{
+		Total: uint(len(t.boards)),
+		Data:  make([]*messageboard.Board, 0, len(t.boards)),
+	}
+	for _, b := range t.boards {
+		c := *b
+		list.Data = append(list.Data, &c)
+	}
+	sort.Slice(list.Data, func(i, j int) bool {
+		return list.Data[i].ID < list.Data[j].ID
+	})
+	return list, nil
+}
+
+func (s *MessageBoardStorage) GetBoard(ctx context.Context, id string) (*messageboard.Board, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	b, ok := t.boards[id]
+	if !ok {
+		return nil, errBoardNotFound
+	}
+	c := *b
+	return &c, nil
+}
+
+func (s *MessageBoardStorage) UpdateBoard(ctx context.Context, b *messageboard.Board) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, ok := t.boards[b.ID]
+	if !ok {
+		return errBoardNotFound
+	}
+	current.Title = b.Title
+	current.Description = b.Description
+	current.Visibility = b.Visibility
+	return nil
+}
+
+func (s *MessageBoardStorage) DeleteBoard(ctx context.Context, id string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.boards[id]; !ok {
+		return errBoardNotFound
+	}
+	delete(t.boards, id)
+	return nil
+}
+
+var errBoardNotFound = messageboard.NewNotFoundError("not_found", "board was not found")
+
+func (s *MessageBoardStorage) Tags(ctx context.Context, boardID string) (*messageboard.TagList, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, msg := range t.msgs {
+		if !match(msg, &messageboard.ListOptions{BoardID: boardID}) {
+			continue
+		}
+		for _, tag := range msg.Tags {
+			counts[tag]++
+		}
+	}
+
+	list := &messageboard.TagList{
+		Total: uint(len(counts)),
+		Data:  make([]*messageboard.TagCount, 0, len(counts)),
+	}
+	for tag, n := range counts {
+		list.Data = append(list.Data, &messageboard.TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(list.Data, func(i, j int) bool {
+		if list.Data[i].Count != list.Data[j].Count {
+			return list.Data[i].Count > list.Data[j].Count
+		}
+		return list.Data[i].Tag < list.Data[j].Tag
+	})
+	return list, nil
+}
+
+// PurgeTrash permanently removes the messages moved to the trash before the given time,
+// from every tenant.
+func (s *MessageBoardStorage) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for _, t := range s.tenants {
+		n += t.purgeTrash(before)
+	}
+	return n, nil
+}
+
+func (t *tenantStorage) purgeTrash(before time.Time) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var n int64
+	for id, msg := range t.msgs {
+		if msg.IsDeleted() && msg.DeletionTime.Before(before) {
+			delete(t.msgs, id)
+			delete(t.revisions, id)
+			t.index.remove(id)
+			n++
+		}
+	}
+	return n
+}
+
+// ReserveKey implements messageboard.IdempotencyStore, the keys are kept
+// apart by tenant.
+func (s *MessageBoardStorage) ReserveKey(ctx context.Context, k *messageboard.IdempotencyKey) (*messageboard.IdempotencyKey, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	t.sweepKeys(now)
+
+	if saved, ok := t.idempotencyKeys[k.Key]; ok && saved.ExpirationTime.After(now) {
+		c := *saved
+		return &c, nil
+	}
+	c := *k
+	t.idempotencyKeys[k.Key] = &c
+	return nil, nil
+}
+
+// sweepKeyInterval is how often the expired idempotency keys are removed.
+const sweepKeyInterval = time.Minute
+
+// sweepKeys removes the expired idempotency keys from time to time, this way
+// the memory doesn't grow forever.
+func (t *tenantStorage) sweepKeys(now time.Time) {
+	if now.Sub(t.lastSweep) < sweepKeyInterval {
+		return
+	}
+	for key, saved := range t.idempotencyKeys {
+		if !saved.ExpirationTime.After(now) {
+			delete(t.idempotencyKeys, key)
+		}
+	}
+	t.lastSweep = now
+}
+
+func (s *MessageBoardStorage) CompleteKey(ctx context.Context, key string, statusCode int, body []byte) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k, ok := t.idempotencyKeys[key]
+	if !ok {
+		return messageboard.NewNotFoundError("not_found", "idempotency key was not found")
+	}
+	k.StatusCode = statusCode
+	k.Body = append([]byte(nil), body...)
+	return nil
+}
+
+func (s *MessageBoardStorage) ReleaseKey(ctx context.Context, key string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.idempotencyKeys, key)
+	return nil
+}
+
+// LoadCSV replaces all messages of the default tenant by the ones inside of initialCSV.
+func (s *MessageBoardStorage) LoadCSV(initialCSV string) error {
+	f, err := os.Open(initialCSV)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	msgs := make(map[string]*messageboard.Message)
+	idx := newIndex()
+	err = messageboard.ReadCSV(f, func(msg *messageboard.Message) error {
+		msg.CreationTime = msg.CreationTime.UTC()
+		msg.ContentHash = messageboard.ContentHash(msg)
+		msgs[msg.ID] = msg
+		idx.add(msg)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t, err := s.tenant(context.Background())
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.msgs = msgs
+	t.index = idx
+	t.revisions = make(map[string][]*messageboard.Revision)
+	t.mu.Unlock()
+	return nil
+}
+
+// clone returns a copy of msg, this way callers are not able to change
+// what is stored without calling Update.
+func clone(msg *messageboard.Message) *messageboard.Message {
+	c := *msg
+	if msg.UpdateTime != nil {
+		t := *msg.UpdateTime
+		c.UpdateTime = &t
+	}
+	if msg.DeletionTime != nil {
+		t := *msg.DeletionTime
+		c.DeletionTime = &t
+	}
+	if msg.ModerationTime != nil {
+		t := *msg.ModerationTime
+		c.ModerationTime = &t
+	}
+	if msg.Tags != nil {
+		c.Tags = append([]string(nil), msg.Tags...)
+	}
+	c.Replies = nil
+	return &c
+}
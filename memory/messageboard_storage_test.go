@@ -0,0 +1,31 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/guilherme-santos/messageboard"
+	"github.com/guilherme-santos/messageboard/memory"
+	"github.com/guilherme-santos/messageboard/storagetest"
+)
+
+func TestMessageBoardStorage(t *testing.T) {
+	storagetest.Run(t, func() messageboard.Storage {
+		return memory.NewMessageBoardStorage()
+	})
+}
+
+func TestMessageBoardStorage_LoadCSV(t *testing.T) {
+	storagetest.RunLoadCSV(t, memory.NewMessageBoardStorage())
+}
+
+func TestMessageBoardStorage_PurgeTrash(t *testing.T) {
+	storagetest.RunPurgeTrash(t, memory.NewMessageBoardStorage())
+}
+
+func TestMessageBoardStorage_TenantIsolation(t *testing.T) {
+	storagetest.RunTenantIsolation(t, memory.NewMessageBoardStorage())
+}
+
+func TestMessageBoardStorage_IdempotencyStore(t *testing.T) {
+	storagetest.RunIdempotencyStore(t, memory.NewMessageBoardStorage())
+}
@@ -2,35 +2,153 @@ package messageboard
 
 import (
 	"context"
+	"fmt"
+	"net/mail"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Message represents a message inside of the system.
 type Message struct {
-	ID           string    `json:"id" bson:"_id"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	Text         string    `json:"text"`
+	ID      string `json:"id" bson:"_id"`
+	BoardID string `json:"board_id,omitempty" bson:"board_id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Text    string `json:"text"`
+	// Tags are normalized to lowercase by Validate.
+	Tags         []string  `json:"tags,omitempty" bson:"tags,omitempty"`
 	CreationTime time.Time `json:"creation_time" bson:"creation_time"`
+	// Version starts at 1 and it's incremented on every update, it's used to
+	// detect concurrent updates.
+	Version int64 `json:"version,omitempty" bson:"version"`
+	// ParentID is set on replies, it's the id of the message being replied.
+	ParentID string `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	// ReplyCount is the number of replies, replies in the trash are not counted.
+	ReplyCount int64 `json:"reply_count,omitempty" bson:"reply_count"`
+	// Replies is only set by Service.Thread.
+	Replies []*Message `json:"replies,omitempty" bson:"-"`
+	// Status is set on creation and changed by the moderators, messages created
+	// before moderation exist have no status, see CurrentStatus.
+	Status Status `json:"status,omitempty" bson:"status,omitempty"`
+	// StatusReason, ModerationTime and ModeratedBy are set by the moderators.
+	StatusReason   string     `json:"status_reason,omitempty" bson:"status_reason,omitempty"`
+	ModerationTime *time.Time `json:"moderation_time,omitempty" bson:"moderation_time,omitempty"`
+	ModeratedBy    string     `json:"moderated_by,omitempty" bson:"moderated_by,omitempty"`
+	// ContentHash is set by the storages on every change, see messageboard.ContentHash.
+	ContentHash string `json:"-" bson:"content_hash,omitempty"`
+	// UpdateTime and UpdatedBy are set on every update.
+	UpdateTime *time.Time `json:"update_time,omitempty" bson:"update_time,omitempty"`
+	UpdatedBy  string     `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	// DeletionTime is set when the message is moved to the trash.
+	DeletionTime *time.Time `json:"deletion_time,omitempty" bson:"deletion_time,omitempty"`
+	DeletedBy    string     `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
+	// Merged is only set by Service.Create, when the message is a duplicate
+	// returned instead of creating a new one, see DuplicateMerge.
+	Merged bool `json:"-" bson:"-"`
+	// Score and Highlights are only set when listing with full-text search.
+	Score      float64             `json:"score,omitempty" bson:"score,omitempty"`
+	Highlights map[string][]string `json:"highlights,omitempty" bson:"-"`
 }
 
+// LastModified returns when the message was last updated or moderated, or
+// created if it was neither.
+func (msg *Message) LastModified() time.Time {
+	t := msg.CreationTime
+	if msg.UpdateTime != nil && msg.UpdateTime.After(t) {
+		t = *msg.UpdateTime
+	}
+	if msg.ModerationTime != nil && msg.ModerationTime.After(t) {
+		t = *msg.ModerationTime
+	}
+	return t
+}
+
+// InBoard returns true if the message belongs to the board. Messages created
+// before boards exist have no board, they belong to the default board.
+func (msg *Message) InBoard(boardID string) bool {
+	return msg.BoardID == boardID || msg.BoardID == "" && boardID == DefaultBoardID
+}
+
+// IsDeleted returns true if the message is in the trash.
+func (msg *Message) IsDeleted() bool {
+	return msg.DeletionTime != nil
+}
+
+const (
+	MaxNameLength  = 100
+	MaxEmailLength = 254
+	MaxTextLength  = 5000
+)
+
+// Validate normalizes and validates the message, reporting all invalid fields at once.
 func (msg *Message) Validate() error {
+	var errs FieldErrors
+
 	msg.Name = strings.TrimSpace(msg.Name)
-	if msg.Name == "" {
-		return NewError("missing_name", `field "name" is missing`)
+	switch {
+	case msg.Name == "":
+		errs.Add("name", "missing_name", `field "name" is missing`)
+	case utf8.RuneCountInString(msg.Name) > MaxNameLength:
+		errs.Add("name", "name_too_long", fmt.Sprintf(`field "name" must have at most %d characters`, MaxNameLength))
+	case !validChars(msg.Name, false):
+		errs.Add("name", "invalid_name", `field "name" has invalid characters`)
 	}
+
 	msg.Email = strings.TrimSpace(msg.Email)
-	if msg.Email == "" {
-		return NewError("missing_email", `field "email" is missing`)
+	switch {
+	case msg.Email == "":
+		errs.Add("email", "missing_email", `field "email" is missing`)
+	case utf8.RuneCountInString(msg.Email) > MaxEmailLength:
+		errs.Add("email", "email_too_long", fmt.Sprintf(`field "email" must have at most %d characters`, MaxEmailLength))
+	case !validEmail(msg.Email):
+		errs.Add("email", "invalid_email", `field "email" is not a valid email address`)
 	}
+
 	msg.Text = strings.TrimSpace(msg.Text)
-	if msg.Text == "" {
-		return NewError("missing_text", `field "text" is missing`)
+	switch {
+	case msg.Text == "":
+		errs.Add("text", "missing_text", `field "text" is missing`)
+	case utf8.RuneCountInString(msg.Text) > MaxTextLength:
+		errs.Add("text", "text_too_long", fmt.Sprintf(`field "text" must have at most %d characters`, MaxTextLength))
+	case !validChars(msg.Text, true):
+		errs.Add("text", "invalid_text", `field "text" has invalid characters`)
 	}
-	return nil
+
+	msg.validateTags(&errs)
+	return errs.Err()
+}
+
+// validChars returns false if s is not a valid utf-8 string or has control
+// characters, line breaks and tabs are accepted only when multiline is true.
+func validChars(s string, multiline bool) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if multiline && (r == '\n' || r == '\r' || r == '\t') {
+			continue
+		}
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validEmail returns true if s is only an email address, without display name.
+func validEmail(s string) bool {
+	if !validChars(s, false) {
+		return false
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return false
+	}
+	return addr.Address == s
 }
 
 //go:generate mockgen -package mock -mock_names Service=Service -destination mock/service.go github.com/guilherme-santos/messageboard Service
@@ -41,16 +159,75 @@ type Service interface {
 	List(context.Context, *ListOptions) (*MessageList, error)
 	Get(_ context.Context, id string) (*Message, error)
 	Update(context.Context, *Message) (*Message, error)
+	// Delete moves the message to the trash, it can be restored later.
+	Delete(_ context.Context, id string) error
+	// Restore moves the message of the board back from the trash.
+	Restore(_ context.Context, boardID, id string) (*Message, error)
+	Revisions(_ context.Context, id string) (*RevisionList, error)
+	Revision(_ context.Context, id string, version int64) (*Revision, error)
+	// Thread returns the message with its replies nested up to depth levels,
+	// oldest replies first.
+	Thread(_ context.Context, id string, depth int) (*Message, error)
+	// Moderate changes the status of a message, e.g. approving or rejecting it.
+	Moderate(_ context.Context, id string, m *Moderation) (*Message, error)
+
+	CreateBoard(context.Context, *Board) (*Board, error)
+	ListBoards(context.Context) (*BoardList, error)
+	GetBoard(_ context.Context, id string) (*Board, error)
+	UpdateBoard(context.Context, *Board) (*Board, error)
+	// DeleteBoard deletes a board without messages, the default board can't be deleted.
+	DeleteBoard(_ context.Context, id string) error
+
+	// Tags returns how many messages of the board have each tag.
+	Tags(_ context.Context, boardID string) (*TagList, error)
 }
 
 //go:generate mockgen -package mock -mock_names Storage=Storage -destination mock/storage.go github.com/guilherme-santos/messageboard Storage
 
 // Storage defines an interface to access messages from a arbitrary storage.
 type Storage interface {
+	// Create increments the ReplyCount of the parent when creating a reply.
+	// Create and Update set the ContentHash of the message.
 	Create(context.Context, *Message) error
 	List(context.Context, *ListOptions) (*MessageList, error)
 	Get(_ context.Context, id string) (*Message, error)
-	Update(context.Context, *Message) error
+	// Update fails with a conflict error when msg.Version is set and it's not
+	// the current version of the message, otherwise the version is incremented
+	// and the previous content is kept as a revision. When msg.Status is set,
+	// e.g. flagged by the content filters, it's saved with msg.StatusReason in
+	// the same write, as a moderation without moderator, otherwise the status
+	// is kept.
+	Update(_ context.Context, msg *Message, updatedBy string) error
+	// Delete moves the message to the trash, deleted messages are hidden from
+	// Get, Update and List, unless ListOptions.Deleted is set. Replies moved to
+	// the trash, or restored from it, update the ReplyCount of the parent.
+	Delete(_ context.Context, id, deletedBy string) error
+	// Restore moves the message back from the trash, messages of other boards
+	// than boardID are not found.
+	Restore(_ context.Context, boardID, id string) error
+	// Revisions returns the revisions of a message, newest first.
+	Revisions(_ context.Context, id string) (*RevisionList, error)
+	Revision(_ context.Context, id string, version int64) (*Revision, error)
+	// Moderate sets the status of a message, it's not an update, the version is
+	// kept and no revision is created.
+	Moderate(_ context.Context, id string, m *Moderation, moderatedBy string) error
+	// FindDuplicate returns the newest message, outside of the trash, created
+	// since the given time with the same ContentHash, board and parent of msg.
+	// It fails with a not found error if there is none.
+	FindDuplicate(_ context.Context, msg *Message, since time.Time) (*Message, error)
+
+	// CreateBoard fails with a conflict error if the board already exists.
+	CreateBoard(context.Context, *Board) error
+	// ListBoards returns all boards stored, sorted by id.
+	ListBoards(context.Context) (*BoardList, error)
+	GetBoard(_ context.Context, id string) (*Board, error)
+	UpdateBoard(context.Context, *Board) error
+	DeleteBoard(_ context.Context, id string) error
+
+	// Tags returns how many messages, outside of the trash, have each tag, most
+	// used first and then by tag. Messages of all boards are counted when
+	// boardID is empty.
+	Tags(_ context.Context, boardID string) (*TagList, error)
 }
 
 // MessageList is a struct containing the list of messages requested with some
@@ -58,18 +235,61 @@ type Storage interface {
 type MessageList struct {
 	Total uint       `json:"total"`
 	Data  []*Message `json:"data"`
+	// Next and Prev are cursors to the next and previous pages, if any.
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// SetCursors sets Next and Prev pointing to the last and first messages in Data,
+// which is sorted by srt.
+//
+// Cursors are built on the sorted fields, so they shouldn't be set on lists
+// sorted by relevance.
+func (list *MessageList) SetCursors(srt Sort, hasPrev, hasNext bool) {
+	if len(list.Data) == 0 {
+		return
+	}
+	if hasPrev {
+		list.Prev = NewCursor(list.Data[0], srt, true).String()
+	}
+	if hasNext {
+		list.Next = NewCursor(list.Data[len(list.Data)-1], srt, false).String()
+	}
 }
 
 // ListOptions is passed to Storage.List to filter/sort/paginate the results.
 type ListOptions struct {
 	PerPage uint
 	Page    uint
+	// Cursor, when set, is used instead of Page.
+	// It's not supported when sorting by relevance.
+	Cursor *Cursor
+	// Query runs a full-text search over text and name, sorting the messages
+	// by relevance, unless Sort is set.
+	Query string
+	// Sort is the order of the messages, DefaultSort is used when empty.
+	Sort Sort
+	// Filters, only messages matching all of them are returned.
+	// BoardID lists only the messages of a board.
+	BoardID string
+	// ParentID lists only the replies of a message.
+	ParentID string
+	Name     string
+	Email    string
+	// Tags lists only messages with at least one tag of each group.
+	Tags [][]string
+	// Status lists only messages with any of the statuses.
+	Status        []Status
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Deleted lists only messages inside of the trash.
+	Deleted bool
 }
 
 const DefaultPerPage = 30
 
 // Load loads values from query string into ListOptions.
-func (opts *ListOptions) Load(values url.Values) {
+func (opts *ListOptions) Load(values url.Values) error {
 	// PerPage
 	if v := values.Get("per_page"); v != "" {
 		perPage, err := strconv.ParseUint(v, 10, 32)
@@ -88,4 +308,90 @@ func (opts *ListOptions) Load(values url.Values) {
 	if opts.Page == 0 {
 		opts.Page = 1
 	}
+	// Sort
+	if v := values.Get("sort"); v != "" {
+		srt, err := ParseSort(v)
+		if err != nil {
+			return err
+		}
+		opts.Sort = srt
+	}
+	// Full-text search
+	opts.Query = strings.TrimSpace(values.Get("q"))
+	// Cursor
+	if v := values.Get("cursor"); v != "" {
+		cursor, err := ParseCursor(v)
+		if err != nil {
+			return err
+		}
+		opts.Cursor = cursor
+	}
+	err := opts.CheckCursor()
+	if err != nil {
+		return err
+	}
+	// Filters
+	opts.Name = strings.TrimSpace(values.Get("name"))
+	opts.Email = strings.TrimSpace(values.Get("email"))
+	opts.Tags = parseTagFilter(values["tag"])
+	if v := values.Get("status"); v != "" {
+		statuses, err := parseStatuses(v)
+		if err != nil {
+			return err
+		}
+		opts.Status = statuses
+	}
+	if v := values.Get("created_after"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return NewValidationError("invalid_created_after", `query string "created_after" must be a RFC3339 date or date-time`)
+		}
+		opts.CreatedAfter = t
+	}
+	if v := values.Get("created_before"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return NewValidationError("invalid_created_before", `query string "created_before" must be a RFC3339 date or date-time`)
+		}
+		opts.CreatedBefore = t
+	}
+	return nil
+}
+
+// SortOrder returns the sort to be used, which is DefaultSort when Sort is empty.
+func (opts *ListOptions) SortOrder() Sort {
+	if len(opts.Sort) == 0 {
+		return DefaultSort
+	}
+	return opts.Sort
+}
+
+// SortByRelevance returns true if the messages should be sorted by the score
+// of the full-text search.
+func (opts *ListOptions) SortByRelevance() bool {
+	return opts.Query != "" && len(opts.Sort) == 0
+}
+
+// CheckCursor checks if Cursor can be used with the other options.
+func (opts *ListOptions) CheckCursor() error {
+	if opts.Cursor == nil {
+		return nil
+	}
+	if opts.SortByRelevance() {
+		return NewValidationError("cursor_not_supported", "cursor pagination is not supported when sorting by relevance, use page instead")
+	}
+	if opts.Cursor.Sort != opts.SortOrder().String() {
+		return NewValidationError("invalid_cursor", "cursor was created with a different sort")
+	}
+	return nil
+}
+
+// parseTime parses a RFC3339 date-time (2006-01-02T15:04:05Z07:00) or
+// only the date (2006-01-02), which is considered as UTC.
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", s)
+	}
+	return t.UTC(), err
 }
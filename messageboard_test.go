@@ -0,0 +1,188 @@
+package messageboard_test
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_Validate(t *testing.T) {
+	msg := &messageboard.Message{
+		Name:  "  Guilherme ",
+		Email: " xguiga@gmail.com",
+		Text:  "My long message\nwith two lines\t",
+	}
+	err := msg.Validate()
+	assert.NoError(t, err)
+	assert.Equal(t, "Guilherme", msg.Name)
+	assert.Equal(t, "xguiga@gmail.com", msg.Email)
+	assert.Equal(t, "My long message\nwith two lines", msg.Text)
+}
+
+func TestMessage_ValidateInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    *messageboard.Message
+		fields []*messageboard.FieldError
+	}{
+		{
+			name: "missing all fields",
+			msg:  &messageboard.Message{Name: " ", Email: "", Text: "\n"},
+			fields: []*messageboard.FieldError{
+				{Field: "name", Code: "missing_name", Message: `field "name" is missing`},
+				{Field: "email", Code: "missing_email", Message: `field "email" is missing`},
+				{Field: "text", Code: "missing_text", Message: `field "text" is missing`},
+			},
+		},
+		{
+			name: "too long",
+			msg: &messageboard.Message{
+				Name:  strings.Repeat("a", messageboard.MaxNameLength+1),
+				Email: strings.Repeat("a", messageboard.MaxEmailLength) + "@gmail.com",
+				Text:  strings.Repeat("á", messageboard.MaxTextLength+1),
+			},
+			fields: []*messageboard.FieldError{
+				{Field: "name", Code: "name_too_long", Message: `field "name" must have at most 100 characters`},
+				{Field: "email", Code: "email_too_long", Message: `field "email" must have at most 254 characters`},
+				{Field: "text", Code: "text_too_long", Message: `field "text" must have at most 5000 characters`},
+			},
+		},
+		{
+			name: "invalid characters",
+			msg: &messageboard.Message{
+				Name:  "Guil\nherme",
+				Email: "xguiga@gmail.com",
+				Text:  "My text\x00",
+			},
+			fields: []*messageboard.FieldError{
+				{Field: "name", Code: "invalid_name", Message: `field "name" has invalid characters`},
+				{Field: "text", Code: "invalid_text", Message: `field "text" has invalid characters`},
+			},
+		},
+		{
+			name: "invalid email",
+			msg: &messageboard.Message{
+				Name:  "Guilherme",
+				Email: "xguiga",
+				Text:  "My text",
+			},
+			fields: []*messageboard.FieldError{
+				{Field: "email", Code: "invalid_email", Message: `field "email" is not a valid email address`},
+			},
+		},
+		{
+			name: "email with display name",
+			msg: &messageboard.Message{
+				Name:  "Guilherme",
+				Email: "Guilherme <xguiga@gmail.com>",
+				Text:  "My text",
+			},
+			fields: []*messageboard.FieldError{
+				{Field: "email", Code: "invalid_email", Message: `field "email" is not a valid email address`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.Validate()
+
+			var mberr *messageboard.Error
+			require.True(t, errors.As(err, &mberr), "expected *messageboard.Error, got: %v", err)
+			assert.Equal(t, messageboard.CategoryValidation, mberr.Category)
+			assert.Equal(t, "invalid_fields", mberr.Code)
+			assert.Equal(t, tt.fields, []*messageboard.FieldError(mberr.Fields))
+		})
+	}
+}
+
+func TestListOptions_Load(t *testing.T) {
+	cursor := &messageboard.Cursor{
+		Sort:         "-creation_time",
+		CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		ID:           "my-id",
+	}
+
+	opts := new(messageboard.ListOptions)
+	err := opts.Load(url.Values{
+		"per_page":       {"10"},
+		"page":           {"2"},
+		"cursor":         {cursor.String()},
+		"name":           {" Guilherme "},
+		"email":          {"xguiga@gmail.com"},
+		"tag":            {"Bug, crash,", "urgent", ","},
+		"status":         {"pending, Rejected"},
+		"created_after":  {"2020-08-01"},
+		"created_before": {"2020-08-12T17:30:00+02:00"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &messageboard.ListOptions{
+		PerPage:       10,
+		Page:          2,
+		Cursor:        cursor,
+		Name:          "Guilherme",
+		Email:         "xguiga@gmail.com",
+		Tags:          [][]string{{"bug", "crash"}, {"urgent"}},
+		Status:        []messageboard.Status{messageboard.StatusPending, messageboard.StatusRejected},
+		CreatedAfter:  time.Date(2020, time.August, 1, 0, 0, 0, 0, time.UTC),
+		CreatedBefore: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+	}, opts)
+}
+
+func TestListOptions_LoadSort(t *testing.T) {
+	srt := messageboard.Sort{{Field: "name"}, {Field: "creation_time", Desc: true}}
+	cursor := messageboard.NewCursor(&messageboard.Message{ID: "my-id", Name: "Guilherme"}, srt, false)
+
+	opts := new(messageboard.ListOptions)
+	err := opts.Load(url.Values{
+		"sort":   {"name,-creation_time"},
+		"q":      {"golang"},
+		"cursor": {cursor.String()},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, srt, opts.Sort)
+	assert.Equal(t, "golang", opts.Query)
+	assert.Equal(t, cursor, opts.Cursor)
+	assert.False(t, opts.SortByRelevance())
+}
+
+func TestListOptions_LoadDefaults(t *testing.T) {
+	opts := new(messageboard.ListOptions)
+	err := opts.Load(url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+	}, opts)
+}
+
+func TestListOptions_LoadInvalid(t *testing.T) {
+	tests := []struct {
+		values url.Values
+		code   string
+	}{
+		{url.Values{"cursor": {"invalid"}}, "invalid_cursor"},
+		{url.Values{"sort": {"text"}}, "invalid_sort"},
+		{url.Values{"sort": {"name"}, "cursor": {messageboard.NewCursor(&messageboard.Message{ID: "my-id"}, messageboard.DefaultSort, false).String()}}, "invalid_cursor"},
+		{url.Values{"q": {"golang"}, "cursor": {messageboard.NewCursor(&messageboard.Message{ID: "my-id"}, messageboard.DefaultSort, false).String()}}, "cursor_not_supported"},
+		{url.Values{"created_after": {"yesterday"}}, "invalid_created_after"},
+		{url.Values{"created_before": {"12/08/2020"}}, "invalid_created_before"},
+		{url.Values{"status": {"published"}}, "invalid_status"},
+	}
+	for _, tt := range tests {
+		err := new(messageboard.ListOptions).Load(tt.values)
+
+		var mberr *messageboard.Error
+		if assert.True(t, errors.As(err, &mberr), "expected *messageboard.Error, got: %v", err) {
+			assert.Equal(t, messageboard.CategoryValidation, mberr.Category)
+			assert.Equal(t, tt.code, mberr.Code)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/guilherme-santos/messageboard (interfaces: IdempotencyStore)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	messageboard "github.com/guilherme-santos/messageboard"
+	reflect "reflect"
+)
+
+// IdempotencyStore is a mock of IdempotencyStore interface
+type IdempotencyStore struct {
+	ctrl     *gomock.Controller
+	recorder *IdempotencyStoreMockRecorder
+}
+
+// IdempotencyStoreMockRecorder is the mock recorder for IdempotencyStore
+type IdempotencyStoreMockRecorder struct {
+	mock *IdempotencyStore
+}
+
+// NewIdempotencyStore creates a new mock instance
+func NewIdempotencyStore(ctrl *gomock.Controller) *IdempotencyStore {
+	mock := &IdempotencyStore{ctrl: ctrl}
+	mock.recorder = &IdempotencyStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *IdempotencyStore) EXPECT() *IdempotencyStoreMockRecorder {
+	return m.recorder
+}
+
+// CompleteKey mocks base method
+func (m *IdempotencyStore) CompleteKey(arg0 context.Context, arg1 string, arg2 int, arg3 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteKey", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteKey indicates an expected call of CompleteKey
+func (mr *IdempotencyStoreMockRecorder) CompleteKey(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteKey", reflect.TypeOf((*IdempotencyStore)(nil).CompleteKey), arg0, arg1, arg2, arg3)
+}
+
+// ReleaseKey mocks base method
+func (m *IdempotencyStore) ReleaseKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseKey indicates an expected call of ReleaseKey
+func (mr *IdempotencyStoreMockRecorder) ReleaseKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseKey", reflect.TypeOf((*IdempotencyStore)(nil).ReleaseKey), arg0, arg1)
+}
+
+// ReserveKey mocks base method
+func (m *IdempotencyStore) ReserveKey(arg0 context.Context, arg1 *messageboard.IdempotencyKey) (*messageboard.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveKey", arg0, arg1)
+	ret0, _ := ret[0].(*messageboard.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveKey indicates an expected call of ReserveKey
+func (mr *IdempotencyStoreMockRecorder) ReserveKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveKey", reflect.TypeOf((*IdempotencyStore)(nil).ReserveKey), arg0, arg1)
+}
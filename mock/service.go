@@ -49,6 +49,49 @@ func (mr *ServiceMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*Service)(nil).Create), arg0, arg1)
 }
 
+// CreateBoard mocks base method
+func (m *Service) CreateBoard(arg0 context.Context, arg1 *messageboard.Board) (*messageboard.Board, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBoard", arg0, arg1)
+	ret0, _ := ret[0].(*messageboard.Board)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBoard indicates an expected call of CreateBoard
+func (mr *ServiceMockRecorder) CreateBoard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBoard", reflect.TypeOf((*Service)(nil).CreateBoard), arg0, arg1)
+}
+
+// Delete mocks base method
+func (m *Service) Delete(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *ServiceMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*Service)(nil).Delete), arg0, arg1)
+}
+
+// DeleteBoard mocks base method
+func (m *Service) DeleteBoard(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBoard", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBoard indicates an expected call of DeleteBoard
+func (mr *ServiceMockRecorder) DeleteBoard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBoard", reflect.TypeOf((*Service)(nil).DeleteBoard), arg0, arg1)
+}
+
 // Get mocks base method
 func (m *Service) Get(arg0 context.Context, arg1 string) (*messageboard.Message, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +107,21 @@ func (mr *ServiceMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Service)(nil).Get), arg0, arg1)
 }
 
+// GetBoard mocks base method
+func (m *Service) GetBoard(arg0 context.Context, arg1 string) (*messageboard.Board, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoard", arg0, arg1)
+	ret0, _ := ret[0].(*messageboard.Board)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoard indicates an expected call of GetBoard
+func (mr *ServiceMockRecorder) GetBoard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoard", reflect.TypeOf((*Service)(nil).GetBoard), arg0, arg1)
+}
+
 // List mocks base method
 func (m *Service) List(arg0 context.Context, arg1 *messageboard.ListOptions) (*messageboard.MessageList, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +137,111 @@ func (mr *ServiceMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*Service)(nil).List), arg0, arg1)
 }
 
+// ListBoards mocks base method
+func (m *Service) ListBoards(arg0 context.Context) (*messageboard.BoardList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBoards", arg0)
+	ret0, _ := ret[0].(*messageboard.BoardList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBoards indicates an expected call of ListBoards
+func (mr *ServiceMockRecorder) ListBoards(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBoards", reflect.TypeOf((*Service)(nil).ListBoards), arg0)
+}
+
+// Moderate mocks base method
+func (m *Service) Moderate(arg0 context.Context, arg1 string, arg2 *messageboard.Moderation) (*messageboard.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Moderate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*messageboard.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Moderate indicates an expected call of Moderate
+func (mr *ServiceMockRecorder) Moderate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Moderate", reflect.TypeOf((*Service)(nil).Moderate), arg0, arg1, arg2)
+}
+
+// Restore mocks base method
+func (m *Service) Restore(arg0 context.Context, arg1, arg2 string) (*messageboard.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*messageboard.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore
+func (mr *ServiceMockRecorder) Restore(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*Service)(nil).Restore), arg0, arg1, arg2)
+}
+
+// Revision mocks base method
+func (m *Service) Revision(arg0 context.Context, arg1 string, arg2 int64) (*messageboard.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revision", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*messageboard.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revision indicates an expected call of Revision
+func (mr *ServiceMockRecorder) Revision(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revision", reflect.TypeOf((*Service)(nil).Revision), arg0, arg1, arg2)
+}
+
+// Revisions mocks base method
+func (m *Service) Revisions(arg0 context.Context, arg1 string) (*messageboard.RevisionList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revisions", arg0, arg1)
+	ret0, _ := ret[0].(*messageboard.RevisionList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revisions indicates an expected call of Revisions
+func (mr *ServiceMockRecorder) Revisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revisions", reflect.TypeOf((*Service)(nil).Revisions), arg0, arg1)
+}
+
+// Tags mocks base method
+func (m *Service) Tags(arg0 context.Context, arg1 string) (*messageboard.TagList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tags", arg0, arg1)
+	ret0, _ := ret[0].(*messageboard.TagList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tags indicates an expected call of Tags
+func (mr *ServiceMockRecorder) Tags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tags", reflect.TypeOf((*Service)(nil).Tags), arg0, arg1)
+}
+
+// Thread mocks base method
+func (m *Service) Thread(arg0 context.Context, arg1 string, arg2 int) (*messageboard.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Thread", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*messageboard.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Thread indicates an expected call of Thread
+func (mr *ServiceMockRecorder) Thread(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Thread", reflect.TypeOf((*Service)(nil).Thread), arg0, arg1, arg2)
+}
+
 // Update mocks base method
 func (m *Service) Update(arg0 context.Context, arg1 *messageboard.Message) (*messageboard.Message, error) {
 	m.ctrl.T.Helper()
@@ -93,3 +256,18 @@ func (mr *ServiceMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*Service)(nil).Update), arg0, arg1)
 }
+
+// UpdateBoard mocks base method
+func (m *Service) UpdateBoard(arg0 context.Context, arg1 *messageboard.Board) (*messageboard.Board, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBoard", arg0, arg1)
+	ret0, _ := ret[0].(*messageboard.Board)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBoard indicates an expected call of UpdateBoard
+func (mr *ServiceMockRecorder) UpdateBoard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBoard", reflect.TypeOf((*Service)(nil).UpdateBoard), arg0, arg1)
+}
@@ -9,6 +9,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	messageboard "github.com/guilherme-santos/messageboard"
 	reflect "reflect"
+	time "time"
 )
 
 // Storage is a mock of Storage interface
@@ -48,6 +49,63 @@ func (mr *StorageMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*Storage)(nil).Create), arg0, arg1)
 }
 
+// CreateBoard mocks base method
+func (m *Storage) CreateBoard(arg0 context.Context, arg1 *messageboard.Board) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBoard", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBoard indicates an expected call of CreateBoard
+func (mr *StorageMockRecorder) CreateBoard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBoard", reflect.TypeOf((*Storage)(nil).CreateBoard), arg0, arg1)
+}
+
+// Delete mocks base method
+func (m *Storage) Delete(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *StorageMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*Storage)(nil).Delete), arg0, arg1, arg2)
+}
+
+// DeleteBoard mocks base method
+func (m *Storage) DeleteBoard(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBoard", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBoard indicates an expected call of DeleteBoard
+func (mr *StorageMockRecorder) DeleteBoard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBoard", reflect.TypeOf((*Storage)(nil).DeleteBoard), arg0, arg1)
+}
+
+// FindDuplicate mocks base method
+func (m *Storage) FindDuplicate(arg0 context.Context, arg1 *messageboard.Message, arg2 time.Time) (*messageboard.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDuplicate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*messageboard.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDuplicate indicates an expected call of FindDuplicate
+func (mr *StorageMockRecorder) FindDuplicate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicate", reflect.TypeOf((*Storage)(nil).FindDuplicate), arg0, arg1, arg2)
+}
+
 // Get mocks base method
 func (m *Storage) Get(arg0 context.Context, arg1 string) (*messageboard.Message, error) {
 	m.ctrl.T.Helper()
@@ -63,6 +121,21 @@ func (mr *StorageMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Storage)(nil).Get), arg0, arg1)
 }
 
+// GetBoard mocks base method
+func (m *Storage) GetBoard(arg0 context.Context, arg1 string) (*messageboard.Board, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBoard", arg0, arg1)
+	ret0, _ := ret[0].(*messageboard.Board)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBoard indicates an expected call of GetBoard
+func (mr *StorageMockRecorder) GetBoard(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBoard", reflect.TypeOf((*Storage)(nil).GetBoard), arg0, arg1)
+}
+
 // List mocks base method
 func (m *Storage) List(arg0 context.Context, arg1 *messageboard.ListOptions) (*messageboard.MessageList, error) {
 	m.ctrl.T.Helper()
@@ -78,16 +151,118 @@ func (mr *StorageMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*Storage)(nil).List), arg0, arg1)
 }
 
+// ListBoards mocks base method
+func (m *Storage) ListBoards(arg0 context.Context) (*messageboard.BoardList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBoards", arg0)
+	ret0, _ := ret[0].(*messageboard.BoardList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBoards indicates an expected call of ListBoards
+func (mr *StorageMockRecorder) ListBoards(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBoards", reflect.TypeOf((*Storage)(nil).ListBoards), arg0)
+}
+
+// Moderate mocks base method
+func (m *Storage) Moderate(arg0 context.Context, arg1 string, arg2 *messageboard.Moderation, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Moderate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Moderate indicates an expected call of Moderate
+func (mr *StorageMockRecorder) Moderate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Moderate", reflect.TypeOf((*Storage)(nil).Moderate), arg0, arg1, arg2, arg3)
+}
+
+// Restore mocks base method
+func (m *Storage) Restore(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore
+func (mr *StorageMockRecorder) Restore(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*Storage)(nil).Restore), arg0, arg1, arg2)
+}
+
+// Revision mocks base method
+func (m *Storage) Revision(arg0 context.Context, arg1 string, arg2 int64) (*messageboard.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revision", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*messageboard.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revision indicates an expected call of Revision
+func (mr *StorageMockRecorder) Revision(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revision", reflect.TypeOf((*Storage)(nil).Revision), arg0, arg1, arg2)
+}
+
+// Revisions mocks base method
+func (m *Storage) Revisions(arg0 context.Context, arg1 string) (*messageboard.RevisionList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revisions", arg0, arg1)
+	ret0, _ := ret[0].(*messageboard.RevisionList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revisions indicates an expected call of Revisions
+func (mr *StorageMockRecorder) Revisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revisions", reflect.TypeOf((*Storage)(nil).Revisions), arg0, arg1)
+}
+
+// Tags mocks base method
+func (m *Storage) Tags(arg0 context.Context, arg1 string) (*messageboard.TagList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tags", arg0, arg1)
+	ret0, _ := ret[0].(*messageboard.TagList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tags indicates an expected call of Tags
+func (mr *StorageMockRecorder) Tags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tags", reflect.TypeOf((*Storage)(nil).Tags), arg0, arg1)
+}
+
 // Update mocks base method
-func (m *Storage) Update(arg0 context.Context, arg1 *messageboard.Message) error {
+func (m *Storage) Update(arg0 context.Context, arg1 *messageboard.Message, arg2 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update
-func (mr *StorageMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
+func (mr *StorageMockRecorder) Update(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*Storage)(nil).Update), arg0, arg1, arg2)
+}
+
+// UpdateBoard mocks base method
+func (m *Storage) UpdateBoard(arg0 context.Context, arg1 *messageboard.Board) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBoard", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBoard indicates an expected call of UpdateBoard
+func (mr *StorageMockRecorder) UpdateBoard(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*Storage)(nil).Update), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBoard", reflect.TypeOf((*Storage)(nil).UpdateBoard), arg0, arg1)
 }
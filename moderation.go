@@ -0,0 +1,94 @@
+package messageboard
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Status is where the message is in the moderation workflow.
+type Status string
+
+const (
+	// StatusPending messages are waiting for a moderator.
+	StatusPending Status = "pending"
+	// StatusApproved messages are visible to everyone.
+	StatusApproved Status = "approved"
+	// StatusRejected messages were refused by a moderator.
+	StatusRejected Status = "rejected"
+	// StatusFlagged messages need the attention of a moderator.
+	StatusFlagged Status = "flagged"
+)
+
+// Valid returns true if s is one of the known statuses.
+func (s Status) Valid() bool {
+	switch s {
+	case StatusPending, StatusApproved, StatusRejected, StatusFlagged:
+		return true
+	}
+	return false
+}
+
+// CurrentStatus returns the status of the message, messages created before
+// moderation exist have no status, they are approved.
+func (msg *Message) CurrentStatus() Status {
+	if msg.Status == "" {
+		return StatusApproved
+	}
+	return msg.Status
+}
+
+// HasStatus returns true if the message has any of the statuses, or if statuses is empty.
+func (msg *Message) HasStatus(statuses []Status) bool {
+	if len(statuses) == 0 {
+		return true
+	}
+	for _, s := range statuses {
+		if msg.CurrentStatus() == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStatuses parses a comma separated list of statuses.
+func parseStatuses(v string) ([]Status, error) {
+	var statuses []Status
+	for _, s := range strings.Split(v, ",") {
+		status := Status(strings.ToLower(strings.TrimSpace(s)))
+		if !status.Valid() {
+			return nil, NewValidationError("invalid_status", `query string "status" must be pending, approved, rejected or flagged`)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+const MaxReasonLength = 500
+
+// Moderation is the decision of a moderator about a message.
+type Moderation struct {
+	Status Status `json:"status"`
+	// Reason is required when rejecting a message.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Validate normalizes and validates the moderation, reporting all invalid fields at once.
+func (m *Moderation) Validate() error {
+	var errs FieldErrors
+
+	if !m.Status.Valid() {
+		errs.Add("status", "invalid_status", `field "status" must be pending, approved, rejected or flagged`)
+	}
+
+	m.Reason = strings.TrimSpace(m.Reason)
+	switch {
+	case m.Reason == "" && m.Status == StatusRejected:
+		errs.Add("reason", "missing_reason", `field "reason" is missing`)
+	case utf8.RuneCountInString(m.Reason) > MaxReasonLength:
+		errs.Add("reason", "reason_too_long", fmt.Sprintf(`field "reason" must have at most %d characters`, MaxReasonLength))
+	case !validChars(m.Reason, true):
+		errs.Add("reason", "invalid_reason", `field "reason" has invalid characters`)
+	}
+	return errs.Err()
+}
@@ -2,10 +2,11 @@ package mongodb
 
 import (
 	"context"
-	"encoding/csv"
-	"fmt"
-	"io"
+	"errors"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/guilherme-santos/messageboard"
@@ -18,46 +19,264 @@ import (
 )
 
 // MessageBoardStorage is a mongodb implementation of messageboard.Storage
+//
+// The data of each tenant, taken from the context of each call, is kept in its
+// own database or collections, according to the Tenancy. The data of the default
+// tenant is kept where it was before tenants exist.
 type MessageBoardStorage struct {
-	client *mongo.Client
-	db     *mongo.Database
-	coll   *mongo.Collection
+	client   *mongo.Client
+	database string
+	tenancy  Tenancy
+
+	mu      sync.Mutex
+	tenants map[string]*tenantStorage
+}
+
+// tenantStorage has the collections of a single tenant.
+type tenantStorage struct {
+	coll            *mongo.Collection
+	revisions       *mongo.Collection
+	boards          *mongo.Collection
+	idempotencyKeys *mongo.Collection
 }
 
-func NewMessageBoardStorage(client *mongo.Client) *MessageBoardStorage {
-	s := &MessageBoardStorage{client: client}
-	s.db = s.client.Database("messageboard")
-	s.coll = s.db.Collection("messages")
+// Tenancy defines how the data of the tenants are kept apart.
+type Tenancy int
+
+const (
+	// TenantDatabase keeps each tenant in its own database, named <database>_<tenant>.
+	TenantDatabase Tenancy = iota
+	// TenantCollection keeps all tenants in the same database, in collections
+	// prefixed by <tenant>_, e.g. acme_messages.
+	TenantCollection
+)
+
+// DefaultDatabase is the database used when no other is given.
+const DefaultDatabase = "messageboard"
+
+// Option configures a MessageBoardStorage.
+type Option func(*MessageBoardStorage)
+
+// WithDatabase sets the database of the default tenant, the others use it as prefix.
+func WithDatabase(name string) Option {
+	return func(s *MessageBoardStorage) {
+		s.database = name
+	}
+}
+
+// WithTenancy sets how the data of the tenants are kept apart, by default each
+// tenant has its own database.
+func WithTenancy(tenancy Tenancy) Option {
+	return func(s *MessageBoardStorage) {
+		s.tenancy = tenancy
+	}
+}
+
+func NewMessageBoardStorage(client *mongo.Client, opts ...Option) *MessageBoardStorage {
+	s := &MessageBoardStorage{
+		client:   client,
+		database: DefaultDatabase,
+		tenancy:  TenantDatabase,
+		tenants:  make(map[string]*tenantStorage),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
 	return s
 }
 
+// collections returns the collections of tenant.
+func (s *MessageBoardStorage) collections(tenant string) *tenantStorage {
+	db := s.client.Database(s.database)
+	var prefix string
+	if tenant != messageboard.DefaultTenant {
+		switch s.tenancy {
+		case TenantCollection:
+			prefix = tenant + "_"
+		default:
+			db = s.client.Database(s.database + "_" + tenant)
+		}
+	}
+	return &tenantStorage{
+		coll:            db.Collection(prefix + "messages"),
+		revisions:       db.Collection(prefix + "revisions"),
+		boards:          db.Collection(prefix + "boards"),
+		idempotencyKeys: db.Collection(prefix + "idempotency_keys"),
+	}
+}
+
+// tenant returns the collections of the tenant in ctx, there is no way to reach
+// the data of another tenant from them. The indexes are created the first time
+// a tenant is used.
+func (s *MessageBoardStorage) tenant(ctx context.Context) (*tenantStorage, error) {
+	name := messageboard.TenantFromContext(ctx)
+	if !messageboard.ValidTenant(name) {
+		return nil, messageboard.ErrInvalidTenant
+	}
+
+	s.mu.Lock()
+	t, ok := s.tenants[name]
+	s.mu.Unlock()
+	if ok {
+		return t, nil
+	}
+
+	// The indexes are created without holding the lock, so the other tenants
+	// don't wait for them. Creating them twice, when a new tenant gets
+	// concurrent requests, is harmless.
+	t = s.collections(name)
+	err := t.createIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants[name] = t
+	return t, nil
+}
+
+// CreateIndexes creates the indexes used by the queries of the tenant in ctx,
+// it's safe to call it when the indexes already exist.
+func (s *MessageBoardStorage) CreateIndexes(ctx context.Context) error {
+	name := messageboard.TenantFromContext(ctx)
+	if !messageboard.ValidTenant(name) {
+		return messageboard.ErrInvalidTenant
+	}
+	return s.collections(name).createIndexes(ctx)
+}
+
+func (t *tenantStorage) createIndexes(ctx context.Context) error {
+	_, err := t.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Default sort, also used by cursors.
+			Keys: bson.D{{Key: "creation_time", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "board_id", Value: 1}, {Key: "creation_time", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "name", Value: 1}, {Key: "creation_time", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "email", Value: 1}, {Key: "creation_time", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "creation_time", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "tags", Value: 1}, {Key: "creation_time", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{
+			// Used to find duplicates.
+			Keys: bson.D{{Key: "content_hash", Value: 1}, {Key: "creation_time", Value: -1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{
+				"content_hash": bson.M{"$exists": true},
+			}),
+		},
+		{
+			Keys: bson.D{{Key: "parent_id", Value: 1}, {Key: "creation_time", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{
+				"parent_id": bson.M{"$exists": true},
+			}),
+		},
+		{
+			// Full-text search, mongodb allows only one text index per collection.
+			Keys: bson.D{{Key: "name", Value: "text"}, {Key: "text", Value: "text"}},
+			Options: options.Index().
+				SetName("full_text").
+				SetWeights(bson.M{"name": 2, "text": 1}).
+				SetDefaultLanguage("english"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = t.revisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "message_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// MongoDB removes the expired keys by itself.
+	_, err = t.idempotencyKeys.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiration_time", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
 func (s *MessageBoardStorage) Create(ctx context.Context, msg *messageboard.Message) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
 	msg.ID = uuid.New().String()
-	msg.CreationTime = time.Now().UTC()
-	_, err := s.coll.InsertOne(ctx, msg)
+	// MongoDB stores times with millisecond precision, truncate it to return
+	// the same value that will be read later.
+	msg.CreationTime = time.Now().UTC().Truncate(time.Millisecond)
+	msg.Version = 1
+	msg.ReplyCount = 0
+	// The fields below are only set by other operations, never on creation.
+	msg.UpdateTime = nil
+	msg.UpdatedBy = ""
+	msg.DeletionTime = nil
+	msg.DeletedBy = ""
+	msg.ModerationTime = nil
+	msg.ModeratedBy = ""
+	msg.Score = 0
+	msg.Highlights = nil
+	msg.Replies = nil
+	msg.ContentHash = messageboard.ContentHash(msg)
+	_, err = t.coll.InsertOne(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return t.addReplies(ctx, msg.ParentID, 1)
+}
+
+// addReplies adds n to the reply_count of the message parentID, if any.
+func (t *tenantStorage) addReplies(ctx context.Context, parentID string, n int) error {
+	if parentID == "" {
+		return nil
+	}
+	_, err := t.coll.UpdateOne(ctx, bson.M{"_id": parentID}, bson.M{
+		"$inc": bson.M{"reply_count": n},
+	})
 	return err
 }
 
 func (s *MessageBoardStorage) List(ctx context.Context, opts *messageboard.ListOptions) (*messageboard.MessageList, error) {
-	mgoOpts := options.Find().
-		SetLimit(int64(opts.PerPage)).
-		SetSkip(int64(opts.PerPage * (opts.Page - 1))).
-		SetSort(bson.M{"creation_time": -1})
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = opts.CheckCursor()
+	if err != nil {
+		return nil, err
+	}
 
 	list := new(messageboard.MessageList)
 
+	filter := listFilter(opts)
+
+	var hasPrev, hasNext bool
+
 	g, ctx := errgroup.WithContext(ctx)
 	// Goroutine to get list of results.
 	g.Go(func() error {
-		cursor, err := s.coll.Find(ctx, bson.D{}, mgoOpts)
-		if err != nil {
-			return err
-		}
-		return cursor.All(ctx, &list.Data)
+		var err error
+		list.Data, hasPrev, hasNext, err = t.findPage(ctx, filter, opts)
+		return err
 	})
 	// Goroutine to get total of results.
 	g.Go(func() error {
-		total, err := s.coll.CountDocuments(ctx, bson.D{}, options.Count())
+		total, err := t.coll.CountDocuments(ctx, filter, options.Count())
 		if err != nil {
 			return err
 		}
@@ -73,14 +292,196 @@ func (s *MessageBoardStorage) List(ctx context.Context, opts *messageboard.ListO
 	if list.Data == nil {
 		list.Data = make([]*messageboard.Message, 0)
 	}
+	if !opts.SortByRelevance() {
+		list.SetCursors(opts.SortOrder(), hasPrev, hasNext)
+	}
 	return list, nil
 }
 
+// listFilter returns the filter matching the messages requested in opts.
+func listFilter(opts *messageboard.ListOptions) bson.M {
+	filter := bson.M{"deletion_time": bson.M{"$exists": opts.Deleted}}
+	if opts.Query != "" {
+		filter["$text"] = bson.M{"$search": opts.Query}
+	}
+	if opts.BoardID == messageboard.DefaultBoardID {
+		// Messages created before boards exist are in the default board.
+		filter["board_id"] = bson.M{"$in": bson.A{opts.BoardID, nil}}
+	} else if opts.BoardID != "" {
+		filter["board_id"] = opts.BoardID
+	}
+	if opts.ParentID != "" {
+		filter["parent_id"] = opts.ParentID
+	}
+	if opts.Name != "" {
+		filter["name"] = opts.Name
+	}
+	if opts.Email != "" {
+		filter["email"] = opts.Email
+	}
+	if len(opts.Status) > 0 {
+		var statuses bson.A
+		for _, s := range opts.Status {
+			statuses = append(statuses, s)
+			if s == messageboard.StatusApproved {
+				// Messages created before moderation exist are approved.
+				statuses = append(statuses, nil)
+			}
+		}
+		filter["status"] = bson.M{"$in": statuses}
+	}
+	if len(opts.Tags) > 0 {
+		var and bson.A
+		for _, anyOf := range opts.Tags {
+			and = append(and, bson.M{"tags": bson.M{"$in": anyOf}})
+		}
+		filter["$and"] = and
+	}
+
+	creationTime := bson.M{}
+	if !opts.CreatedAfter.IsZero() {
+		creationTime["$gt"] = opts.CreatedAfter
+	}
+	if !opts.CreatedBefore.IsZero() {
+		creationTime["$lt"] = opts.CreatedBefore
+	}
+	if len(creationTime) > 0 {
+		filter["creation_time"] = creationTime
+	}
+	return filter
+}
+
+var textScore = bson.M{"$meta": "textScore"}
+
+// findPage finds the messages matching filter inside of the page requested in opts,
+// and reports if there are messages before and after it.
+func (t *tenantStorage) findPage(ctx context.Context, filter bson.M, opts *messageboard.ListOptions) (msgs []*messageboard.Message, hasPrev, hasNext bool, err error) {
+	// We always ask for one more message, this way we know if there is a next page.
+	limit := int64(opts.PerPage)
+	if limit > 0 {
+		limit++
+	}
+	mgoOpts := options.Find().SetLimit(limit)
+	if opts.Query != "" {
+		mgoOpts.SetProjection(bson.M{"score": textScore})
+	}
+
+	srt := opts.SortOrder()
+	query := filter
+	c := opts.Cursor
+	switch {
+	case c == nil:
+		var skip int64
+		if opts.Page > 1 {
+			skip = int64(opts.PerPage * (opts.Page - 1))
+		}
+		mgoOpts.SetSkip(skip).SetSort(mongoSort(srt, false))
+		if opts.SortByRelevance() {
+			mgoOpts.SetSort(append(bson.D{{Key: "score", Value: textScore}}, mongoSort(srt, false)...))
+		}
+		hasPrev = skip > 0
+	case c.Prev:
+		// Look backwards from the cursor, reversing the results later.
+		query = bson.M{"$and": bson.A{filter, cursorFilter(c, srt, false, false)}}
+		mgoOpts.SetSort(mongoSort(srt, true))
+	default:
+		query = bson.M{"$and": bson.A{filter, cursorFilter(c, srt, true, false)}}
+		mgoOpts.SetSort(mongoSort(srt, false))
+	}
+
+	cursor, err := t.coll.Find(ctx, query, mgoOpts)
+	if err != nil {
+		return nil, false, false, err
+	}
+	err = cursor.All(ctx, &msgs)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	more := limit > 0 && int64(len(msgs)) == limit
+	if more {
+		msgs = msgs[:len(msgs)-1]
+	}
+
+	switch {
+	case c == nil:
+		hasNext = more
+	case c.Prev:
+		for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+			msgs[i], msgs[j] = msgs[j], msgs[i]
+		}
+		hasPrev = more
+		hasNext, err = t.exists(ctx, bson.M{"$and": bson.A{filter, cursorFilter(c, srt, true, true)}})
+	default:
+		hasNext = more
+		hasPrev, err = t.exists(ctx, bson.M{"$and": bson.A{filter, cursorFilter(c, srt, false, true)}})
+	}
+	return msgs, hasPrev, hasNext, err
+}
+
+// sortFields returns the fields of srt followed by the _id, which is used to
+// break ties.
+func sortFields(srt messageboard.Sort) messageboard.Sort {
+	fields := append(messageboard.Sort{}, srt...)
+	return append(fields, messageboard.SortField{Field: "_id", Desc: srt[0].Desc})
+}
+
+// mongoSort converts srt to a mongodb sort, in the reverse order when reverse is true.
+func mongoSort(srt messageboard.Sort, reverse bool) bson.D {
+	var d bson.D
+	for _, f := range sortFields(srt) {
+		dir := 1
+		if f.Desc != reverse {
+			dir = -1
+		}
+		d = append(d, bson.E{Key: f.Field, Value: dir})
+	}
+	return d
+}
+
+// cursorFilter returns a filter matching the messages at one side of the cursor in
+// a list sorted by srt, the ones after it when after is true, the ones before it
+// otherwise. The message pointed by the cursor is only matched when inclusive is true.
+func cursorFilter(c *messageboard.Cursor, srt messageboard.Sort, after, inclusive bool) bson.M {
+	fields := sortFields(srt)
+
+	// For a sort by a,b the filter is: a > c.a OR (a = c.a AND b > c.b)
+	var or bson.A
+	for i, f := range fields {
+		cond := bson.M{}
+		for _, prev := range fields[:i] {
+			cond[prev.Field] = c.Value(prev.Field)
+		}
+
+		op := "$lt"
+		if f.Desc != after {
+			op = "$gt"
+		}
+		if inclusive && i == len(fields)-1 {
+			op += "e"
+		}
+		cond[f.Field] = bson.M{op: c.Value(f.Field)}
+		or = append(or, cond)
+	}
+	return bson.M{"$or": or}
+}
+
+// exists returns true if at least one message matches filter.
+func (t *tenantStorage) exists(ctx context.Context, filter interface{}) (bool, error) {
+	n, err := t.coll.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
 func (s *MessageBoardStorage) Get(ctx context.Context, id string) (*messageboard.Message, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var msg *messageboard.Message
-	err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&msg)
+	err = t.coll.FindOne(ctx, byID(id)).Decode(&msg)
 	if err == mongo.ErrNoDocuments {
-		return nil, messageboard.NewError("not_found", "message was not found")
+		return nil, messageboard.NewNotFoundError("not_found", "message was not found")
 	}
 	if err != nil {
 		return nil, err
@@ -88,67 +489,535 @@ func (s *MessageBoardStorage) Get(ctx context.Context, id string) (*messageboard
 	return msg, nil
 }
 
-func (s *MessageBoardStorage) Update(ctx context.Context, msg *messageboard.Message) error {
-	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": msg.ID}, bson.M{
+func (s *MessageBoardStorage) Update(ctx context.Context, msg *messageboard.Message, updatedBy string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter := byID(msg.ID)
+	if msg.Version != 0 {
+		// The version is part of the filter, so it's checked and incremented atomically.
+		filter["version"] = msg.Version
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	set := bson.M{
+		"name":         msg.Name,
+		"email":        msg.Email,
+		"text":         msg.Text,
+		"tags":         msg.Tags,
+		"content_hash": messageboard.ContentHash(msg),
+		"update_time":  now,
+		"updated_by":   updatedBy,
+	}
+	if msg.Status != "" {
+		set["status"] = msg.Status
+		set["status_reason"] = msg.StatusReason
+		set["moderation_time"] = now
+		set["moderated_by"] = ""
+	}
+	// We need the previous content to keep it as a revision.
+	var prev *messageboard.Message
+	err = t.coll.FindOneAndUpdate(ctx, filter, bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&prev)
+	if err == mongo.ErrNoDocuments {
+		if msg.Version != 0 {
+			// Find out if it didn't match because of the version.
+			found, err := t.exists(ctx, byID(msg.ID))
+			if err != nil {
+				return err
+			}
+			if found {
+				return errVersionConflict
+			}
+		}
+		return messageboard.NewNotFoundError("not_found", "message was not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	// MongoDB 3.6 has no transactions, if it fails the message is updated
+	// without the revision.
+	_, err = t.revisions.InsertOne(ctx, messageboard.NewRevision(prev, updatedBy))
+	return err
+}
+
+func (s *MessageBoardStorage) Moderate(ctx context.Context, id string, m *messageboard.Moderation, moderatedBy string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := t.coll.UpdateOne(ctx, byID(id), bson.M{
 		"$set": bson.M{
-			"name":  msg.Name,
-			"email": msg.Email,
-			"text":  msg.Text,
+			"status":          m.Status,
+			"status_reason":   m.Reason,
+			"moderation_time": time.Now().UTC().Truncate(time.Millisecond),
+			"moderated_by":    moderatedBy,
 		},
 	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return messageboard.NewNotFoundError("not_found", "message was not found")
+	}
+	return nil
+}
+
+func (s *MessageBoardStorage) FindDuplicate(ctx context.Context, msg *messageboard.Message, since time.Time) (*messageboard.Message, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"content_hash":  messageboard.ContentHash(msg),
+		"creation_time": bson.M{"$gte": since},
+		"deletion_time": bson.M{"$exists": false},
+		"board_id":      msg.BoardID,
+		"parent_id":     msg.ParentID,
+	}
+	if msg.BoardID == "" || msg.BoardID == messageboard.DefaultBoardID {
+		// Messages created before boards exist are in the default board.
+		filter["board_id"] = bson.M{"$in": bson.A{messageboard.DefaultBoardID, "", nil}}
+	}
+	if msg.ParentID == "" {
+		filter["parent_id"] = nil
+	}
+
+	var dup *messageboard.Message
+	err = t.coll.FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "creation_time", Value: -1}})).Decode(&dup)
+	if err == mongo.ErrNoDocuments {
+		return nil, messageboard.NewNotFoundError("not_found", "message was not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dup, nil
+}
+
+var errVersionConflict = messageboard.NewConflictError("version_conflict", "message was updated by someone else, get its last version and try again")
+
+func (s *MessageBoardStorage) Delete(ctx context.Context, id, deletedBy string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	var msg *messageboard.Message
+	err = t.coll.FindOneAndUpdate(ctx, byID(id), bson.M{
+		"$set": bson.M{
+			"deletion_time": time.Now().UTC().Truncate(time.Millisecond),
+			"deleted_by":    deletedBy,
+		},
+	}, parentOnly).Decode(&msg)
+	if err == mongo.ErrNoDocuments {
+		return messageboard.NewNotFoundError("not_found", "message was not found")
+	}
+	if err != nil {
+		return err
+	}
+	return t.addReplies(ctx, msg.ParentID, -1)
+}
+
+// parentOnly returns only the parent_id of the message found by FindOneAndUpdate.
+var parentOnly = options.FindOneAndUpdate().SetProjection(bson.M{"parent_id": 1})
+
+func (s *MessageBoardStorage) Restore(ctx context.Context, boardID, id string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"_id":           id,
+		"board_id":      boardID,
+		"deletion_time": bson.M{"$exists": true},
+	}
+	if boardID == messageboard.DefaultBoardID {
+		// Messages created before boards exist are in the default board.
+		filter["board_id"] = bson.M{"$in": bson.A{messageboard.DefaultBoardID, "", nil}}
+	}
+	var msg *messageboard.Message
+	err = t.coll.FindOneAndUpdate(ctx, filter, bson.M{
+		"$unset": bson.M{
+			"deletion_time": "",
+			"deleted_by":    "",
+		},
+	}, parentOnly).Decode(&msg)
+	if err == mongo.ErrNoDocuments {
+		return messageboard.NewNotFoundError("not_found", "message was not found in the trash")
+	}
+	if err != nil {
+		return err
+	}
+	return t.addReplies(ctx, msg.ParentID, 1)
+}
+
+func (s *MessageBoardStorage) Revisions(ctx context.Context, id string) (*messageboard.RevisionList, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := t.revisions.Find(ctx, bson.M{"message_id": id}, options.Find().SetSort(bson.M{"version": -1}))
+	if err != nil {
+		return nil, err
+	}
+	list := &messageboard.RevisionList{
+		Data: make([]*messageboard.Revision, 0),
+	}
+	err = cursor.All(ctx, &list.Data)
+	if err != nil {
+		return nil, err
+	}
+	list.Total = uint(len(list.Data))
+	return list, nil
+}
+
+func (s *MessageBoardStorage) Revision(ctx context.Context, id string, version int64) (*messageboard.Revision, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rev *messageboard.Revision
+	err = t.revisions.FindOne(ctx, bson.M{"message_id": id, "version": version}).Decode(&rev)
+	if err == mongo.ErrNoDocuments {
+		return nil, messageboard.NewNotFoundError("not_found", "revision was not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+func (s *MessageBoardStorage) CreateBoard(ctx context.Context, b *messageboard.Board) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	b.CreationTime = time.Now().UTC().Truncate(time.Millisecond)
+	_, err = t.boards.InsertOne(ctx, b)
+	if isDuplicateKey(err) {
+		return messageboard.NewConflictError("board_already_exists", "board already exists")
+	}
 	return err
 }
 
-func (s *MessageBoardStorage) LoadCSV(initialCSV string) error {
-	f, err := os.Open(initialCSV)
+// isDuplicateKey returns true if err was caused by a duplicate key in an unique index.
+func isDuplicateKey(err error) bool {
+	var we mongo.WriteException
+	if !errors.As(err, &we) {
+		return false
+	}
+	for _, e := range we.WriteErrors {
+		if e.Code == 11000 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MessageBoardStorage) ListBoards(ctx context.Context) (*messageboard.BoardList, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := t.boards.Find(ctx, bson.D{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	list := &messageboard.BoardList{
+		Data: make([]*messageboard.Board, 0),
+	}
+	err = cursor.All(ctx, &list.Data)
+	if err != nil {
+		return nil, err
+	}
+	list.Total = uint(len(list.Data))
+	return list, nil
+}
+
+func (s *MessageBoardStorage) GetBoard(ctx context.Context, id string) (*messageboard.Board, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var b *messageboard.Board
+	err = t.boards.FindOne(ctx, bson.M{"_id": id}).Decode(&b)
+	if err == mongo.ErrNoDocuments {
+		return nil, errBoardNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *MessageBoardStorage) UpdateBoard(ctx context.Context, b *messageboard.Board) error {
+	t, err := s.tenant(ctx)
 	if err != nil {
 		return err
 	}
 
-	ctx := context.Background()
+	res, err := t.boards.UpdateOne(ctx, bson.M{"_id": b.ID}, bson.M{
+		"$set": bson.M{
+			"title":       b.Title,
+			"description": b.Description,
+			"visibility":  b.Visibility,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errBoardNotFound
+	}
+	return nil
+}
 
-	r := csv.NewReader(f)
-	r.FieldsPerRecord = 5
+func (s *MessageBoardStorage) DeleteBoard(ctx context.Context, id string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
 
-	// Remove current collection to load csv from scratch
-	err = s.coll.Drop(ctx)
+	res, err := t.boards.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
 		return err
 	}
+	if res.DeletedCount == 0 {
+		return errBoardNotFound
+	}
+	return nil
+}
+
+var errBoardNotFound = messageboard.NewNotFoundError("not_found", "board was not found")
+
+func (s *MessageBoardStorage) Tags(ctx context.Context, boardID string) (*messageboard.TagList, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	var i int
-	for {
-		record, err := r.Read()
-		if err == io.EOF {
-			break
+	cursor, err := t.coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: listFilter(&messageboard.ListOptions{BoardID: boardID})}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$tags",
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := &messageboard.TagList{
+		Data: make([]*messageboard.TagCount, 0),
+	}
+	err = cursor.All(ctx, &list.Data)
+	if err != nil {
+		return nil, err
+	}
+	list.Total = uint(len(list.Data))
+	return list, nil
+}
+
+// PurgeTrash permanently removes the messages moved to the trash before the given time,
+// together with their revisions, from every tenant.
+func (s *MessageBoardStorage) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	names, err := s.tenantNames(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	for _, name := range names {
+		t, err := s.tenant(messageboard.ContextWithTenant(ctx, name))
+		if err != nil {
+			return n, err
 		}
+		purged, err := t.purgeTrash(ctx, before)
+		n += purged
 		if err != nil {
-			return err
+			return n, err
 		}
+	}
+	return n, nil
+}
 
-		i++
-		if i == 1 {
-			// It's the header, we can ignore it
-			continue
+// tenantNames returns the tenants with data in the database.
+func (s *MessageBoardStorage) tenantNames(ctx context.Context) ([]string, error) {
+	var (
+		names []string
+		err   error
+	)
+	switch s.tenancy {
+	case TenantCollection:
+		names, err = s.client.Database(s.database).ListCollectionNames(ctx, bson.M{
+			"name": bson.M{"$regex": "_messages$"},
+		})
+		for i := range names {
+			names[i] = strings.TrimSuffix(names[i], "_messages")
 		}
-
-		creationTime, err := time.Parse(time.RFC3339, record[4])
-		if err != nil {
-			return fmt.Errorf("invalid time on line %d: %v", i, err)
+	default:
+		names, err = s.client.ListDatabaseNames(ctx, bson.M{
+			"name": bson.M{"$regex": "^" + regexp.QuoteMeta(s.database+"_")},
+		})
+		for i := range names {
+			names[i] = strings.TrimPrefix(names[i], s.database+"_")
 		}
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		msg := &messageboard.Message{
-			ID:           record[0],
-			Name:         record[1],
-			Email:        record[2],
-			Text:         record[3],
-			CreationTime: creationTime,
+	tenants := []string{messageboard.DefaultTenant}
+	for _, name := range names {
+		if messageboard.ValidTenant(name) && name != messageboard.DefaultTenant {
+			tenants = append(tenants, name)
 		}
+	}
+	return tenants, nil
+}
 
-		_, err = s.coll.InsertOne(ctx, msg)
-		if err != nil {
-			return err
-		}
+func (t *tenantStorage) purgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	filter := bson.M{
+		"deletion_time": bson.M{"$lt": before},
+	}
+	ids, err := t.coll.Distinct(ctx, "_id", filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	res, err := t.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	_, err = t.revisions.DeleteMany(ctx, bson.M{"message_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// ReserveKey implements messageboard.IdempotencyStore, the keys are kept
+// apart by tenant.
+func (s *MessageBoardStorage) ReserveKey(ctx context.Context, k *messageboard.IdempotencyKey) (*messageboard.IdempotencyKey, error) {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = t.idempotencyKeys.InsertOne(ctx, k)
+	if !isDuplicateKey(err) {
+		return nil, err
+	}
+
+	// MongoDB removes the expired keys only from time to time, so the key could
+	// be expired and not removed yet.
+	res, err := t.idempotencyKeys.ReplaceOne(ctx, bson.M{
+		"_id":             k.Key,
+		"expiration_time": bson.M{"$lte": time.Now().UTC()},
+	}, k)
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount > 0 {
+		return nil, nil
+	}
+
+	var saved *messageboard.IdempotencyKey
+	err = t.idempotencyKeys.FindOne(ctx, bson.M{"_id": k.Key}).Decode(&saved)
+	if err == mongo.ErrNoDocuments {
+		// It was removed in the meantime.
+		return s.ReserveKey(ctx, k)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (s *MessageBoardStorage) CompleteKey(ctx context.Context, key string, statusCode int, body []byte) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := t.idempotencyKeys.UpdateOne(ctx, bson.M{"_id": key}, bson.M{
+		"$set": bson.M{
+			"status_code": statusCode,
+			"body":        body,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return messageboard.NewNotFoundError("not_found", "idempotency key was not found")
 	}
 	return nil
 }
+
+func (s *MessageBoardStorage) ReleaseKey(ctx context.Context, key string) error {
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.idempotencyKeys.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// LoadCSV replaces all messages of the default tenant by the ones inside of initialCSV.
+func (s *MessageBoardStorage) LoadCSV(initialCSV string) error {
+	f, err := os.Open(initialCSV)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx := context.Background()
+	t, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Remove all messages to load csv from scratch, we don't drop the
+	// collection to keep its indexes.
+	_, err = t.coll.DeleteMany(ctx, bson.D{})
+	if err != nil {
+		return err
+	}
+	_, err = t.revisions.DeleteMany(ctx, bson.D{})
+	if err != nil {
+		return err
+	}
+
+	return messageboard.ReadCSV(f, func(msg *messageboard.Message) error {
+		msg.ContentHash = messageboard.ContentHash(msg)
+		_, err := t.coll.InsertOne(ctx, msg)
+		return err
+	})
+}
+
+// byID returns a filter matching the message with the given id, as long as
+// it's not in the trash.
+func byID(id string) bson.M {
+	return bson.M{
+		"_id":           id,
+		"deletion_time": bson.M{"$exists": false},
+	}
+}
@@ -0,0 +1,93 @@
+package mongodb_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+	"github.com/guilherme-santos/messageboard/mongodb"
+	"github.com/guilherme-santos/messageboard/storagetest"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newClient connects to the MongoDB set in MONGODB_TEST_URL, the database used
+// by the storage is dropped between tests, so never point it to a real one.
+func newClient(t *testing.T) *mongo.Client {
+	url := os.Getenv("MONGODB_TEST_URL")
+	if url == "" {
+		t.Skip("MONGODB_TEST_URL is not set, skipping mongodb tests")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(url))
+	if err != nil {
+		t.Fatal("unable to connect to mongodb:", err)
+	}
+	t.Cleanup(func() {
+		client.Disconnect(context.Background())
+	})
+	return client
+}
+
+func newStorage(t *testing.T, client *mongo.Client, opts ...mongodb.Option) *mongodb.MessageBoardStorage {
+	// Databases of the tenants used by the tests are dropped as well.
+	for _, db := range []string{"messageboard", "messageboard_acme", "messageboard_globex"} {
+		err := client.Database(db).Drop(context.Background())
+		if err != nil {
+			t.Fatal("unable to drop database:", err)
+		}
+	}
+	storage := mongodb.NewMessageBoardStorage(client, opts...)
+	err := storage.CreateIndexes(context.Background())
+	if err != nil {
+		t.Fatal("unable to create indexes:", err)
+	}
+	return storage
+}
+
+func TestMessageBoardStorage(t *testing.T) {
+	client := newClient(t)
+
+	storagetest.Run(t, func() messageboard.Storage {
+		return newStorage(t, client)
+	})
+}
+
+func TestMessageBoardStorage_PurgeTrash(t *testing.T) {
+	storage := newStorage(t, newClient(t))
+	storagetest.RunPurgeTrash(t, storage)
+}
+
+func TestMessageBoardStorage_LoadCSV(t *testing.T) {
+	storage := newStorage(t, newClient(t))
+	storagetest.RunLoadCSV(t, storage)
+}
+
+func TestMessageBoardStorage_IdempotencyStore(t *testing.T) {
+	storage := newStorage(t, newClient(t))
+	storagetest.RunIdempotencyStore(t, storage)
+}
+
+func TestMessageBoardStorage_TenantIsolation(t *testing.T) {
+	client := newClient(t)
+
+	tests := []struct {
+		name    string
+		tenancy mongodb.Tenancy
+	}{
+		{"database", mongodb.TenantDatabase},
+		{"collection", mongodb.TenantCollection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newStorage(t, client, mongodb.WithTenancy(tt.tenancy))
+			storagetest.RunTenantIsolation(t, storage)
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package messageboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7386) to msg. The patch is
+// applied to the json representation of msg, so it uses the json field names.
+//
+// The message is not validated, it's up to the caller.
+func (msg *Message) ApplyMergePatch(patch []byte) error {
+	var p interface{}
+	err := json.Unmarshal(patch, &p)
+	if err != nil {
+		return invalidPatch(err.Error())
+	}
+	return msg.patch(func(doc interface{}) (interface{}, error) {
+		return mergePatch(doc, p), nil
+	})
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+// patchOperation is an operation of a JSON Patch.
+type patchOperation struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	From string `json:"from"`
+	// Value is nil when it's missing, which is different of null.
+	Value json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch applies a JSON Patch (RFC 6902) to msg. The patch is applied
+// to the json representation of msg, so the paths use the json field names.
+//
+// A failing "test" operation returns a conflict error, any other problem with
+// the patch returns a validation error. The message is not validated, it's up
+// to the caller.
+func (msg *Message) ApplyJSONPatch(patch []byte) error {
+	// Operations are not pointers, so null operations are invalid instead of nil.
+	var ops []patchOperation
+	err := json.Unmarshal(patch, &ops)
+	if err != nil {
+		return invalidPatch(err.Error())
+	}
+	return msg.patch(func(doc interface{}) (interface{}, error) {
+		for i := range ops {
+			doc, err = applyOperation(doc, &ops[i])
+			if _, ok := err.(*Error); ok {
+				return nil, err
+			}
+			if err != nil {
+				return nil, invalidPatch(fmt.Sprintf("operation %d: %v", i, err))
+			}
+		}
+		return doc, nil
+	})
+}
+
+func applyOperation(doc interface{}, op *patchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf(`"value" is missing`)
+		}
+		err := json.Unmarshal(op.Value, &value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return addValue(doc, path, value)
+	case "remove":
+		if len(path) == 0 {
+			return nil, fmt.Errorf("the whole document can't be removed")
+		}
+		return removeValue(doc, path)
+	case "replace":
+		if len(path) > 0 {
+			doc, err = removeValue(doc, path)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return addValue(doc, path, value)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			value = deepCopy(value)
+		} else {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("a value can't be moved into one of its children")
+			}
+			doc, err = removeValue(doc, from)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return addValue(doc, path, value)
+	case "test":
+		current, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, NewConflictError("patch_test_failed", fmt.Sprintf("value of %q is not the expected one", op.Path))
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePointer parses a JSON Pointer (RFC 6901).
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("path %q must start with /", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			v, ok := d[token]
+			if !ok {
+				return nil, fmt.Errorf("%q was not found", token)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(token, len(d)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, fmt.Errorf("%q was not found", token)
+		}
+	}
+	return doc, nil
+}
+
+// update calls fn with the parent of the value pointed by path and the last
+// token of path, replacing the parent with the value returned by fn.
+func update(doc interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	child, err := getValue(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = update(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		d[path[0]] = child
+	case []interface{}:
+		i, _ := strconv.Atoi(path[0])
+		d[i] = child
+	}
+	return doc, nil
+}
+
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[token] = value
+			return p, nil
+		case []interface{}:
+			if token == "-" {
+				return append(p, value), nil
+			}
+			i, err := arrayIndex(token, len(p))
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		}
+		return nil, fmt.Errorf("%q can't be added to a value which is not an object or array", token)
+	})
+}
+
+func removeValue(doc interface{}, path []string) (interface{}, error) {
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[token]; !ok {
+				return nil, fmt.Errorf("%q was not found", token)
+			}
+			delete(p, token)
+			return p, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(p)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:i], p[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%q was not found", token)
+	})
+}
+
+// arrayIndex parses token as an array index, which must be at most max.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("%q is not a valid array index", token)
+	}
+	return i, nil
+}
+
+func deepCopy(v interface{}) interface{} {
+	b, _ := json.Marshal(v)
+	var c interface{}
+	json.Unmarshal(b, &c)
+	return c
+}
+
+// patch calls fn with the json representation of msg, replacing msg with the
+// document returned by fn.
+func (msg *Message) patch(fn func(doc interface{}) (interface{}, error)) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	err = json.Unmarshal(b, &doc)
+	if err != nil {
+		return err
+	}
+
+	doc, err = fn(doc)
+	if err != nil {
+		return err
+	}
+
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var patched Message
+	err = json.Unmarshal(b, &patched)
+	if err != nil {
+		return invalidPatch("patched message is invalid: " + err.Error())
+	}
+	*msg = patched
+	return nil
+}
+
+func invalidPatch(msg string) error {
+	return NewValidationError("invalid_patch", msg)
+}
@@ -0,0 +1,96 @@
+package messageboard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+	"github.com/guilherme-santos/messageboard/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPatchMessage() *messageboard.Message {
+	return &messageboard.Message{
+		ID:           "my-id",
+		Name:         "Guilherme",
+		Email:        "xguiga@gmail.com",
+		Text:         "My text goes here",
+		CreationTime: time.Date(2020, time.August, 12, 15, 30, 0, 0, time.UTC),
+		Version:      2,
+	}
+}
+
+func TestMessage_ApplyMergePatch(t *testing.T) {
+	msg := newPatchMessage()
+	err := msg.ApplyMergePatch([]byte(`{"text": "My text was updated", "email": null, "unknown": 1}`))
+	require.NoError(t, err)
+
+	want := newPatchMessage()
+	want.Text = "My text was updated"
+	want.Email = ""
+	assert.Equal(t, want, msg)
+}
+
+func TestMessage_ApplyMergePatchInvalid(t *testing.T) {
+	tests := []string{
+		`{"text": `,
+		`{"text": 10}`,
+		`[]`,
+	}
+	for _, patch := range tests {
+		msg := newPatchMessage()
+		err := msg.ApplyMergePatch([]byte(patch))
+		storagetest.AssertErrorCode(t, "invalid_patch", err)
+		assert.Equal(t, newPatchMessage(), msg, "message should not change on errors")
+	}
+}
+
+func TestMessage_ApplyJSONPatch(t *testing.T) {
+	msg := newPatchMessage()
+	err := msg.ApplyJSONPatch([]byte(`[
+		{"op": "test", "path": "/version", "value": 2},
+		{"op": "replace", "path": "/text", "value": "My text was updated"},
+		{"op": "copy", "from": "/email", "path": "/name"},
+		{"op": "add", "path": "/highlights", "value": {"text": ["a"]}},
+		{"op": "add", "path": "/highlights/text/0", "value": "b"},
+		{"op": "add", "path": "/highlights/text/-", "value": "c"},
+		{"op": "move", "from": "/highlights/text/0", "path": "/other~1field"},
+		{"op": "test", "path": "/other~1field", "value": "b"},
+		{"op": "remove", "path": "/highlights/text/1"}
+	]`))
+	require.NoError(t, err)
+
+	want := newPatchMessage()
+	want.Text = "My text was updated"
+	want.Name = "xguiga@gmail.com"
+	want.Highlights = map[string][]string{"text": {"a"}}
+	// Unknown fields, as "other/field", are ignored.
+	assert.Equal(t, want, msg)
+}
+
+func TestMessage_ApplyJSONPatchInvalid(t *testing.T) {
+	tests := []struct {
+		patch string
+		code  string
+	}{
+		{`{"op": "add"}`, "invalid_patch"},
+		{`[null]`, "invalid_patch"},
+		{`[{"op": "unknown", "path": "/text"}]`, "invalid_patch"},
+		{`[{"op": "add", "path": "/text"}]`, "invalid_patch"},
+		{`[{"op": "add", "path": "text", "value": "a"}]`, "invalid_patch"},
+		{`[{"op": "remove", "path": "/unknown"}]`, "invalid_patch"},
+		{`[{"op": "replace", "path": "/unknown", "value": "a"}]`, "invalid_patch"},
+		{`[{"op": "remove", "path": ""}]`, "invalid_patch"},
+		{`[{"op": "replace", "path": "/text", "value": 10}]`, "invalid_patch"},
+		{`[{"op": "move", "from": "/text", "path": "/text/a"}]`, "invalid_patch"},
+		{`[{"op": "test", "path": "/version", "value": 1}]`, "patch_test_failed"},
+	}
+	for _, tt := range tests {
+		msg := newPatchMessage()
+		err := msg.ApplyJSONPatch([]byte(tt.patch))
+		storagetest.AssertErrorCode(t, tt.code, err)
+		assert.Equal(t, newPatchMessage(), msg, "message should not change on errors")
+	}
+}
@@ -0,0 +1,40 @@
+package messageboard
+
+import "time"
+
+// Revision is the content of a message before an update, every update keeps the
+// content it replaces as a revision, so the message can be reverted to it.
+type Revision struct {
+	MessageID string `json:"message_id" bson:"message_id"`
+	// Version of the message that had this content.
+	Version int64    `json:"version" bson:"version"`
+	Name    string   `json:"name" bson:"name"`
+	Email   string   `json:"email" bson:"email"`
+	Text    string   `json:"text" bson:"text"`
+	Tags    []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	// ChangeTime and ChangedBy are when and by whom the content was changed.
+	ChangeTime time.Time `json:"change_time" bson:"change_time"`
+	ChangedBy  string    `json:"changed_by,omitempty" bson:"changed_by,omitempty"`
+}
+
+// NewRevision returns a revision with the current content of msg, which is being
+// changed by changedBy.
+func NewRevision(msg *Message, changedBy string) *Revision {
+	return &Revision{
+		MessageID: msg.ID,
+		Version:   msg.Version,
+		Name:      msg.Name,
+		Email:     msg.Email,
+		Text:      msg.Text,
+		Tags:      msg.Tags,
+		// Storages keep times with millisecond precision.
+		ChangeTime: time.Now().UTC().Truncate(time.Millisecond),
+		ChangedBy:  changedBy,
+	}
+}
+
+// RevisionList is the list of revisions of a message, newest first.
+type RevisionList struct {
+	Total uint        `json:"total"`
+	Data  []*Revision `json:"data"`
+}
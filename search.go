@@ -0,0 +1,144 @@
+package messageboard
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// stopWords are ignored by the full-text search, they are too common to be relevant.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "if": true, "in": true,
+	"into": true, "is": true, "it": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "such": true, "that": true, "the": true, "their": true,
+	"then": true, "there": true, "these": true, "they": true, "this": true, "to": true,
+	"was": true, "will": true, "with": true,
+}
+
+// Terms splits s into the terms used by the full-text search, it lowercases
+// the words, removes stop words and reduces the words to a naive stem, so
+// "Messages" and "message" are the same term.
+func Terms(s string) []string {
+	var terms []string
+	for _, word := range words(s) {
+		word = strings.ToLower(word)
+		if stopWords[word] {
+			continue
+		}
+		terms = append(terms, stem(word))
+	}
+	return terms
+}
+
+// words splits s in words, anything that is not a letter or a digit is a separator.
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// stem removes the most common english suffixes, it's far from a real stemmer,
+// but good enough to match plurals and simple verb forms.
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "s"} {
+		if len(word) > len(suffix)+2 && strings.HasSuffix(word, suffix) {
+			word = strings.TrimSuffix(word, suffix)
+			break
+		}
+	}
+	// So "message" and "messages" ends up the same.
+	if len(word) > 3 && strings.HasSuffix(word, "e") {
+		word = strings.TrimSuffix(word, "e")
+	}
+	return word
+}
+
+const (
+	maxHighlights      = 3
+	highlightWordsAway = 6
+)
+
+// Highlight returns snippets of s around the words matching terms (as returned
+// by Terms), the matching words are wrapped in <em></em>. The rest of the
+// snippet is html-escaped, so it's safe to render it as html.
+func Highlight(s string, terms []string) []string {
+	if len(terms) == 0 {
+		return nil
+	}
+	lookup := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		lookup[term] = true
+	}
+
+	// Find the position of every word in s.
+	type word struct {
+		start, end int
+		match      bool
+	}
+	var ws []word
+	start := -1
+	for i, r := range s + " " {
+		isWord := unicode.IsLetter(r) || unicode.IsNumber(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			w := strings.ToLower(s[start:i])
+			ws = append(ws, word{
+				start: start,
+				end:   i,
+				match: !stopWords[w] && lookup[stem(w)],
+			})
+			start = -1
+		}
+	}
+
+	var snippets []string
+	for i := 0; i < len(ws) && len(snippets) < maxHighlights; i++ {
+		if !ws[i].match {
+			continue
+		}
+
+		// The snippet goes from some words before the first match until some
+		// words after the last match close to each other.
+		first := i - highlightWordsAway
+		if first < 0 {
+			first = 0
+		}
+		last := i
+		for j := i + 1; j < len(ws) && j <= last+highlightWordsAway; j++ {
+			if ws[j].match {
+				last = j
+			}
+		}
+		end := last + highlightWordsAway
+		if end >= len(ws) {
+			end = len(ws) - 1
+		}
+
+		var b strings.Builder
+		pos := 0
+		if first > 0 {
+			b.WriteString("…")
+			pos = ws[first].start
+		}
+		for _, w := range ws[first : end+1] {
+			b.WriteString(html.EscapeString(s[pos:w.start]))
+			if w.match {
+				b.WriteString("<em>" + html.EscapeString(s[w.start:w.end]) + "</em>")
+			} else {
+				b.WriteString(html.EscapeString(s[w.start:w.end]))
+			}
+			pos = w.end
+		}
+		if end < len(ws)-1 {
+			b.WriteString("…")
+		} else {
+			b.WriteString(html.EscapeString(s[pos:]))
+		}
+		snippets = append(snippets, b.String())
+		i = end
+	}
+	return snippets
+}
@@ -0,0 +1,60 @@
+package messageboard_test
+
+import (
+	"testing"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	terms := messageboard.Terms("The Messages, and the message-board are WORKING!")
+	assert.Equal(t, []string{"messag", "messag", "board", "work"}, terms)
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		exp   []string
+	}{
+		{
+			name:  "single match",
+			text:  "I love my message board",
+			query: "messages",
+			exp:   []string{"I love my <em>message</em> board"},
+		},
+		{
+			name:  "long text",
+			text:  "one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen",
+			query: "eight",
+			exp:   []string{"…two three four five six seven <em>eight</em> nine ten eleven twelve thirteen fourteen…"},
+		},
+		{
+			name:  "close matches are merged",
+			text:  "golang is nice and golang is fast",
+			query: "golang",
+			exp:   []string{"<em>golang</em> is nice and <em>golang</em> is fast"},
+		},
+		{
+			name:  "html is escaped",
+			text:  "<b>golang</b> & go",
+			query: "golang",
+			exp:   []string{"&lt;b&gt;<em>golang</em>&lt;/b&gt; &amp; go"},
+		},
+		{
+			name:  "no match",
+			text:  "I love my message board",
+			query: "golang",
+			exp:   nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, messageboard.Highlight(tt.text, messageboard.Terms(tt.query)))
+		})
+	}
+}
@@ -1,9 +1,27 @@
 package messageboard
 
-import "context"
+import (
+	"context"
+	"sort"
+	"time"
+)
 
 type service struct {
 	storage Storage
+	filters FilterChain
+	// duplicateWindow is how long messages are checked for duplicates, see WithDuplicateDetection.
+	duplicateWindow time.Duration
+	duplicatePolicy DuplicatePolicy
+}
+
+// ServiceOption configures optional behaviours of the service.
+type ServiceOption func(*service)
+
+// WithContentFilters runs filters, in order, on the messages being created or updated.
+func WithContentFilters(filters ...ContentFilter) ServiceOption {
+	return func(s *service) {
+		s.filters = append(s.filters, filters...)
+	}
 }
 
 // NewService returns the default (and likely the only) implementation of messageboard.Service.
@@ -11,10 +29,14 @@ type service struct {
 // For the current use-case this implementation will be really simple, basicaly a proxy for
 // storage, but could be more complex, such as emit events, call another services, etc.
 //
-func NewService(storage Storage) Service {
-	return &service{
+func NewService(storage Storage, opts ...ServiceOption) Service {
+	s := &service{
 		storage: storage,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *service) Create(ctx context.Context, msg *Message) (*Message, error) {
@@ -23,19 +45,121 @@ func (s *service) Create(ctx context.Context, msg *Message) (*Message, error) {
 		return nil, err
 	}
 
+	if msg.BoardID == "" {
+		msg.BoardID = DefaultBoardID
+	}
+	if msg.BoardID != DefaultBoardID {
+		_, err := s.storage.GetBoard(ctx, msg.BoardID)
+		if ErrorCategoryOf(err) == CategoryNotFound {
+			return nil, NewValidationError("board_not_found", "board was not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Messages of the public are only visible after being approved by a moderator.
+	msg.Status = StatusPending
+	msg.StatusReason = ""
+	if _, ok := UserFromContext(ctx); ok {
+		msg.Status = StatusApproved
+	}
+
+	if msg.ParentID != "" {
+		// Messages can't reply to messages the author can't see.
+		parent, err := s.Get(ctx, msg.ParentID)
+		if ErrorCategoryOf(err) == CategoryNotFound || err == nil && !parent.InBoard(msg.BoardID) {
+			return nil, NewValidationError("parent_not_found", "message being replied was not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dup, err := s.findDuplicate(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if dup != nil {
+		if s.duplicatePolicy == DuplicateMerge {
+			// Hidden messages are not returned, only their id.
+			if !visible(ctx, dup) {
+				dup = &Message{ID: dup.ID}
+			}
+			dup.Merged = true
+			return dup, nil
+		}
+		return nil, NewDuplicateError(dup.ID)
+	}
+
+	res, err := s.filterContent(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if res.Action == FilterFlag {
+		msg.Status = StatusFlagged
+		msg.StatusReason = res.Reason
+	}
+
 	err = s.storage.Create(ctx, msg)
 	if err != nil {
 		return nil, err
 	}
-	return s.Get(ctx, msg.ID)
+	// The author sees the message, even if it's not approved yet.
+	return s.storage.Get(ctx, msg.ID)
+}
+
+// visible returns true if msg can be seen by the user in ctx, unauthenticated
+// users only see approved messages.
+func visible(ctx context.Context, msg *Message) bool {
+	_, ok := UserFromContext(ctx)
+	return ok || msg.CurrentStatus() == StatusApproved
+}
+
+// visibleOptions returns opts listing only the messages visible by the user in ctx.
+func visibleOptions(ctx context.Context, opts *ListOptions) *ListOptions {
+	if _, ok := UserFromContext(ctx); ok {
+		return opts
+	}
+	o := *opts
+	o.Status = []Status{StatusApproved}
+	return &o
 }
 
 func (s *service) List(ctx context.Context, opts *ListOptions) (*MessageList, error) {
-	return s.storage.List(ctx, opts)
+	list, err := s.storage.List(ctx, visibleOptions(ctx, opts))
+	if err != nil {
+		return nil, err
+	}
+
+	// Highlights are done here, instead of in each storage, to have the same
+	// behaviour no matter which storage is used.
+	if terms := Terms(opts.Query); len(terms) > 0 {
+		for _, msg := range list.Data {
+			highlights := make(map[string][]string)
+			if h := Highlight(msg.Name, terms); len(h) > 0 {
+				highlights["name"] = h
+			}
+			if h := Highlight(msg.Text, terms); len(h) > 0 {
+				highlights["text"] = h
+			}
+			if len(highlights) > 0 {
+				msg.Highlights = highlights
+			}
+		}
+	}
+	return list, nil
 }
 
 func (s *service) Get(ctx context.Context, id string) (*Message, error) {
-	return s.storage.Get(ctx, id)
+	msg, err := s.storage.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !visible(ctx, msg) {
+		return nil, NewNotFoundError("not_found", "message was not found")
+	}
+	return msg, nil
 }
 
 func (s *service) Update(ctx context.Context, msg *Message) (*Message, error) {
@@ -44,9 +168,198 @@ func (s *service) Update(ctx context.Context, msg *Message) (*Message, error) {
 		return nil, err
 	}
 
-	err = s.storage.Update(ctx, msg)
+	res, err := s.filterContent(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	// The status is only changed by the filters, or by moderating the message.
+	msg.Status = ""
+	msg.StatusReason = ""
+	if res.Action == FilterFlag {
+		msg.Status = StatusFlagged
+		msg.StatusReason = res.Reason
+	}
+
+	user, _ := UserFromContext(ctx)
+	err = s.storage.Update(ctx, msg, user)
 	if err != nil {
 		return nil, err
 	}
 	return s.Get(ctx, msg.ID)
 }
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	user, _ := UserFromContext(ctx)
+	return s.storage.Delete(ctx, id, user)
+}
+
+func (s *service) Restore(ctx context.Context, boardID, id string) (*Message, error) {
+	err := s.storage.Restore(ctx, boardID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *service) Revisions(ctx context.Context, id string) (*RevisionList, error) {
+	return s.storage.Revisions(ctx, id)
+}
+
+func (s *service) Revision(ctx context.Context, id string, version int64) (*Revision, error) {
+	return s.storage.Revision(ctx, id, version)
+}
+
+func (s *service) Moderate(ctx context.Context, id string, m *Moderation) (*Message, error) {
+	err := m.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	user, _ := UserFromContext(ctx)
+	err = s.storage.Moderate(ctx, id, m, user)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// threadSort shows the replies in the order they were written.
+var threadSort = Sort{{Field: "creation_time"}}
+
+// MaxThreadReplies is the maximum number of replies, of all levels, returned by
+// Service.Thread. The replies closer to the message are loaded first, the
+// others can be listed page by page.
+const MaxThreadReplies = 100
+
+func (s *service) Thread(ctx context.Context, id string, depth int) (*Message, error) {
+	msg, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.loadReplies(ctx, msg, depth)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// loadReplies sets the replies of msg, and of its replies, up to depth levels
+// and MaxThreadReplies replies, level by level.
+func (s *service) loadReplies(ctx context.Context, msg *Message, depth int) error {
+	remaining := MaxThreadReplies
+	level := []*Message{msg}
+	for ; depth > 0 && len(level) > 0 && remaining > 0; depth-- {
+		var next []*Message
+		for _, m := range level {
+			if m.ReplyCount == 0 || remaining == 0 {
+				continue
+			}
+			list, err := s.storage.List(ctx, visibleOptions(ctx, &ListOptions{
+				PerPage:  uint(remaining),
+				ParentID: m.ID,
+				Sort:     threadSort,
+			}))
+			if err != nil {
+				return err
+			}
+			m.Replies = list.Data
+			remaining -= len(list.Data)
+			next = append(next, list.Data...)
+		}
+		level = next
+	}
+	return nil
+}
+
+func (s *service) CreateBoard(ctx context.Context, b *Board) (*Board, error) {
+	err := b.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if b.ID == DefaultBoardID {
+		return nil, errBoardExists
+	}
+
+	err = s.storage.CreateBoard(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetBoard(ctx, b.ID)
+}
+
+var errBoardExists = NewConflictError("board_already_exists", "board already exists")
+
+func (s *service) ListBoards(ctx context.Context) (*BoardList, error) {
+	list, err := s.storage.ListBoards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range list.Data {
+		if b.ID == DefaultBoardID {
+			return list, nil
+		}
+	}
+
+	// The default board is only stored after being updated.
+	list.Data = append(list.Data, DefaultBoard())
+	sort.Slice(list.Data, func(i, j int) bool {
+		return list.Data[i].ID < list.Data[j].ID
+	})
+	list.Total++
+	return list, nil
+}
+
+func (s *service) GetBoard(ctx context.Context, id string) (*Board, error) {
+	b, err := s.storage.GetBoard(ctx, id)
+	if ErrorCategoryOf(err) == CategoryNotFound && id == DefaultBoardID {
+		return DefaultBoard(), nil
+	}
+	return b, err
+}
+
+func (s *service) UpdateBoard(ctx context.Context, b *Board) (*Board, error) {
+	err := b.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.storage.UpdateBoard(ctx, b)
+	if ErrorCategoryOf(err) == CategoryNotFound && b.ID == DefaultBoardID {
+		err = s.storage.CreateBoard(ctx, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetBoard(ctx, b.ID)
+}
+
+func (s *service) DeleteBoard(ctx context.Context, id string) error {
+	if id == DefaultBoardID {
+		return NewConflictError("default_board", "the default board can't be deleted")
+	}
+	_, err := s.storage.GetBoard(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Messages in the trash are also checked, they could be restored later.
+	for _, deleted := range []bool{false, true} {
+		list, err := s.storage.List(ctx, &ListOptions{
+			PerPage: 1,
+			Page:    1,
+			BoardID: id,
+			Deleted: deleted,
+		})
+		if err != nil {
+			return err
+		}
+		if list.Total > 0 {
+			return NewConflictError("board_not_empty", "board still has messages, including the ones in the trash")
+		}
+	}
+	return s.storage.DeleteBoard(ctx, id)
+}
+
+func (s *service) Tags(ctx context.Context, boardID string) (*TagList, error) {
+	return s.storage.Tags(ctx, boardID)
+}
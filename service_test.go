@@ -2,11 +2,14 @@ package messageboard_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/guilherme-santos/messageboard"
 	"github.com/guilherme-santos/messageboard/mock"
+	"github.com/guilherme-santos/messageboard/storagetest"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -49,10 +52,113 @@ func TestService_Create(t *testing.T) {
 	assert.Equal(t, expMsg, msg)
 }
 
-// TODO:: implement test for List, today is just a bypass for storage, but it's not
-// been implemented for sake of time.
-// func TestService_List(t *testing.T) {
-// }
+func TestService_CreateReply(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqMsg := &messageboard.Message{
+		Name:     "Guilherme",
+		Email:    "xguiga@gmail.com",
+		Text:     "My reply",
+		ParentID: "parent-id",
+	}
+
+	storage := mock.NewStorage(ctrl)
+	gomock.InOrder(
+		storage.EXPECT().
+			Get(gomock.Any(), "parent-id").
+			Return(&messageboard.Message{ID: "parent-id"}, nil),
+		storage.EXPECT().
+			Create(gomock.Any(), reqMsg).
+			DoAndReturn(func(ctx context.Context, msg *messageboard.Message) error {
+				msg.ID = "reply-id"
+				return nil
+			}),
+		storage.EXPECT().
+			Get(gomock.Any(), "reply-id").
+			Return(reqMsg, nil),
+	)
+
+	svc := messageboard.NewService(storage)
+	msg, err := svc.Create(context.Background(), reqMsg)
+	assert.NoError(t, err)
+	assert.Equal(t, reqMsg, msg)
+}
+
+func TestService_CreateReplyParentNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		Get(gomock.Any(), "parent-id").
+		Return(nil, messageboard.NewNotFoundError("not_found", "message was not found"))
+
+	svc := messageboard.NewService(storage)
+	_, err := svc.Create(context.Background(), &messageboard.Message{
+		Name:     "Guilherme",
+		Email:    "xguiga@gmail.com",
+		Text:     "My reply",
+		ParentID: "parent-id",
+	})
+	assert.Equal(t, messageboard.NewValidationError("parent_not_found", "message being replied was not found"), err)
+}
+
+func TestService_CreateReplyParentHidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	for _, status := range []messageboard.Status{messageboard.StatusPending, messageboard.StatusRejected, messageboard.StatusFlagged} {
+		storage := mock.NewStorage(ctrl)
+		storage.EXPECT().
+			Get(gomock.Any(), "parent-id").
+			Return(&messageboard.Message{ID: "parent-id", Status: status}, nil)
+
+		svc := messageboard.NewService(storage)
+		_, err := svc.Create(context.Background(), &messageboard.Message{
+			Name:     "Guilherme",
+			Email:    "xguiga@gmail.com",
+			Text:     "My reply",
+			ParentID: "parent-id",
+		})
+		assert.Equal(t, messageboard.NewValidationError("parent_not_found", "message being replied was not found"), err, status)
+	}
+}
+
+func TestService_ListHighlights(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+		Query:   "golang",
+	}
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		List(gomock.Any(), opts).
+		Return(&messageboard.MessageList{
+			Total: 2,
+			Data: []*messageboard.Message{
+				{ID: "id-1", Name: "Golang fan", Text: "I love golang", Score: 2},
+				{ID: "id-2", Name: "Guilherme", Text: "Golang is nice", Score: 1},
+			},
+		}, nil)
+
+	ctx := messageboard.ContextWithUser(context.Background(), "moderator")
+
+	svc := messageboard.NewService(storage)
+	list, err := svc.List(ctx, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"name": {"<em>Golang</em> fan"},
+		"text": {"I love <em>golang</em>"},
+	}, list.Data[0].Highlights)
+	assert.Equal(t, map[string][]string{
+		"text": {"<em>Golang</em> is nice"},
+	}, list.Data[1].Highlights)
+}
 
 // TODO:: implement test for Get, today is just a bypass for storage, but it's not
 // been implemented for sake of time.
@@ -75,20 +181,610 @@ func TestService_Update(t *testing.T) {
 		Name:  expMsg.Name,
 		Email: expMsg.Email,
 		Text:  expMsg.Text,
+		// The status can't be changed by updating.
+		Status:       messageboard.StatusApproved,
+		StatusReason: "set by the client",
 	}
 
 	storage := mock.NewStorage(ctrl)
 	storage.EXPECT().
-		Update(gomock.Any(), reqMsg).
+		Update(gomock.Any(), &messageboard.Message{
+			ID:    expMsg.ID,
+			Name:  expMsg.Name,
+			Email: expMsg.Email,
+			Text:  expMsg.Text,
+		}, "moderator").
 		Return(nil)
 	storage.EXPECT().
 		Get(gomock.Any(), expMsg.ID).
 		Return(expMsg, nil)
 
-	ctx := context.Background()
+	ctx := messageboard.ContextWithUser(context.Background(), "moderator")
 
 	svc := messageboard.NewService(storage)
 	msg, err := svc.Update(ctx, reqMsg)
 	assert.NoError(t, err)
 	assert.Equal(t, expMsg, msg)
 }
+
+func TestService_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		Delete(gomock.Any(), "my-id", "moderator").
+		Return(nil)
+
+	ctx := messageboard.ContextWithUser(context.Background(), "moderator")
+
+	svc := messageboard.NewService(storage)
+	err := svc.Delete(ctx, "my-id")
+	assert.NoError(t, err)
+}
+
+func TestService_Restore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expMsg := &messageboard.Message{
+		ID:           "my-id",
+		Name:         "Guilherme",
+		Email:        "xguiga@gmail.com",
+		Text:         "My long message",
+		CreationTime: time.Now().UTC(),
+	}
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		Restore(gomock.Any(), messageboard.DefaultBoardID, expMsg.ID).
+		Return(nil)
+	storage.EXPECT().
+		Get(gomock.Any(), expMsg.ID).
+		Return(expMsg, nil)
+
+	ctx := context.Background()
+
+	svc := messageboard.NewService(storage)
+	msg, err := svc.Restore(ctx, messageboard.DefaultBoardID, expMsg.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, expMsg, msg)
+}
+
+func TestService_Thread(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	threadSort := messageboard.Sort{{Field: "creation_time"}}
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		Get(gomock.Any(), "id-1").
+		Return(&messageboard.Message{ID: "id-1", ReplyCount: 2}, nil)
+	storage.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{PerPage: messageboard.MaxThreadReplies, ParentID: "id-1", Sort: threadSort}).
+		Return(&messageboard.MessageList{
+			Total: 2,
+			Data: []*messageboard.Message{
+				{ID: "id-2", ParentID: "id-1", ReplyCount: 1},
+				// Messages without replies are not queried.
+				{ID: "id-3", ParentID: "id-1"},
+			},
+		}, nil)
+	storage.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{PerPage: messageboard.MaxThreadReplies - 2, ParentID: "id-2", Sort: threadSort}).
+		Return(&messageboard.MessageList{
+			Total: 1,
+			Data: []*messageboard.Message{
+				// Deeper than the depth, its replies are not loaded.
+				{ID: "id-4", ParentID: "id-2", ReplyCount: 1},
+			},
+		}, nil)
+
+	svc := messageboard.NewService(storage)
+	ctx := messageboard.ContextWithUser(context.Background(), "moderator")
+	msg, err := svc.Thread(ctx, "id-1", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, &messageboard.Message{
+		ID:         "id-1",
+		ReplyCount: 2,
+		Replies: []*messageboard.Message{
+			{
+				ID:         "id-2",
+				ParentID:   "id-1",
+				ReplyCount: 1,
+				Replies: []*messageboard.Message{
+					{ID: "id-4", ParentID: "id-2", ReplyCount: 1},
+				},
+			},
+			{ID: "id-3", ParentID: "id-1"},
+		},
+	}, msg)
+}
+
+func TestService_ThreadMaxReplies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	replies := make([]*messageboard.Message, messageboard.MaxThreadReplies)
+	for i := range replies {
+		replies[i] = &messageboard.Message{ID: fmt.Sprintf("reply-%d", i), ParentID: "id-1", ReplyCount: 1}
+	}
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		Get(gomock.Any(), "id-1").
+		Return(&messageboard.Message{ID: "id-1", ReplyCount: 1000}, nil)
+	// The replies of the replies are not loaded, there is no room for them.
+	storage.EXPECT().
+		List(gomock.Any(), gomock.Any()).
+		Return(&messageboard.MessageList{Total: 1000, Data: replies}, nil)
+
+	svc := messageboard.NewService(storage)
+	ctx := messageboard.ContextWithUser(context.Background(), "moderator")
+	msg, err := svc.Thread(ctx, "id-1", 5)
+	assert.NoError(t, err)
+	assert.Len(t, msg.Replies, messageboard.MaxThreadReplies)
+}
+
+func TestService_CreateBoardNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		GetBoard(gomock.Any(), "golang").
+		Return(nil, messageboard.NewNotFoundError("not_found", "board was not found"))
+
+	svc := messageboard.NewService(storage)
+	_, err := svc.Create(context.Background(), &messageboard.Message{
+		BoardID: "golang",
+		Name:    "Guilherme",
+		Email:   "xguiga@gmail.com",
+		Text:    "My text",
+	})
+	assert.Equal(t, messageboard.NewValidationError("board_not_found", "board was not found"), err)
+}
+
+func TestService_CreateReplyOtherBoard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		GetBoard(gomock.Any(), "golang").
+		Return(&messageboard.Board{ID: "golang"}, nil)
+	storage.EXPECT().
+		Get(gomock.Any(), "parent-id").
+		Return(&messageboard.Message{ID: "parent-id"}, nil)
+
+	svc := messageboard.NewService(storage)
+	_, err := svc.Create(context.Background(), &messageboard.Message{
+		BoardID:  "golang",
+		Name:     "Guilherme",
+		Email:    "xguiga@gmail.com",
+		Text:     "My reply",
+		ParentID: "parent-id",
+	})
+	assert.Equal(t, messageboard.NewValidationError("parent_not_found", "message being replied was not found"), err)
+}
+
+func TestService_ListBoards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		ListBoards(gomock.Any()).
+		Return(&messageboard.BoardList{
+			Total: 2,
+			Data: []*messageboard.Board{
+				{ID: "art", Title: "Art"},
+				{ID: "golang", Title: "Golang"},
+			},
+		}, nil)
+
+	svc := messageboard.NewService(storage)
+	list, err := svc.ListBoards(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &messageboard.BoardList{
+		Total: 3,
+		Data: []*messageboard.Board{
+			{ID: "art", Title: "Art"},
+			messageboard.DefaultBoard(),
+			{ID: "golang", Title: "Golang"},
+		},
+	}, list)
+}
+
+func TestService_GetDefaultBoard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		GetBoard(gomock.Any(), messageboard.DefaultBoardID).
+		Return(nil, messageboard.NewNotFoundError("not_found", "board was not found"))
+
+	svc := messageboard.NewService(storage)
+	b, err := svc.GetBoard(context.Background(), messageboard.DefaultBoardID)
+	assert.NoError(t, err)
+	assert.Equal(t, messageboard.DefaultBoard(), b)
+}
+
+func TestService_UpdateDefaultBoard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqBoard := &messageboard.Board{
+		ID:         messageboard.DefaultBoardID,
+		Title:      "Back's Message Board",
+		Visibility: messageboard.VisibilityPrivate,
+	}
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		UpdateBoard(gomock.Any(), reqBoard).
+		Return(messageboard.NewNotFoundError("not_found", "board was not found"))
+	storage.EXPECT().
+		CreateBoard(gomock.Any(), reqBoard).
+		Return(nil)
+	storage.EXPECT().
+		GetBoard(gomock.Any(), messageboard.DefaultBoardID).
+		Return(reqBoard, nil)
+
+	svc := messageboard.NewService(storage)
+	b, err := svc.UpdateBoard(context.Background(), reqBoard)
+	assert.NoError(t, err)
+	assert.Equal(t, reqBoard, b)
+}
+
+func TestService_DeleteBoardNotEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		GetBoard(gomock.Any(), "golang").
+		Return(&messageboard.Board{ID: "golang"}, nil)
+	storage.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{PerPage: 1, Page: 1, BoardID: "golang"}).
+		Return(&messageboard.MessageList{Total: 0}, nil)
+	storage.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{PerPage: 1, Page: 1, BoardID: "golang", Deleted: true}).
+		Return(&messageboard.MessageList{Total: 1}, nil)
+
+	svc := messageboard.NewService(storage)
+	err := svc.DeleteBoard(context.Background(), "golang")
+	assert.Equal(t, messageboard.NewConflictError("board_not_empty", "board still has messages, including the ones in the trash"), err)
+
+	err = svc.DeleteBoard(context.Background(), messageboard.DefaultBoardID)
+	assert.Equal(t, messageboard.NewConflictError("default_board", "the default board can't be deleted"), err)
+}
+
+func TestService_CreateStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status messageboard.Status
+	}{
+		{"public", context.Background(), messageboard.StatusPending},
+		{"authenticated", messageboard.ContextWithUser(context.Background(), "moderator"), messageboard.StatusApproved},
+	}
+	for _, tt := range tests {
+		storage := mock.NewStorage(ctrl)
+		storage.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, msg *messageboard.Message) error {
+				msg.ID = "my-id"
+				return nil
+			})
+		storage.EXPECT().
+			Get(gomock.Any(), "my-id").
+			Return(&messageboard.Message{ID: "my-id", Status: tt.status}, nil)
+
+		reqMsg := &messageboard.Message{
+			Name:         "Guilherme",
+			Email:        "xguiga@gmail.com",
+			Text:         "My text",
+			Status:       messageboard.StatusRejected,
+			StatusReason: "set by the client",
+		}
+		svc := messageboard.NewService(storage)
+		// The author gets the message back, even when it's pending.
+		msg, err := svc.Create(tt.ctx, reqMsg)
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.status, reqMsg.Status, tt.name)
+		assert.Empty(t, reqMsg.StatusReason, tt.name)
+		assert.Equal(t, tt.status, msg.Status, tt.name)
+	}
+}
+
+func TestService_GetNotApproved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pending := &messageboard.Message{ID: "my-id", Status: messageboard.StatusPending}
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(pending, nil).
+		Times(2)
+
+	svc := messageboard.NewService(storage)
+	_, err := svc.Get(context.Background(), "my-id")
+	assert.Equal(t, messageboard.NewNotFoundError("not_found", "message was not found"), err)
+
+	msg, err := svc.Get(messageboard.ContextWithUser(context.Background(), "moderator"), "my-id")
+	assert.NoError(t, err)
+	assert.Equal(t, pending, msg)
+}
+
+func TestService_ListApprovedOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		List(gomock.Any(), &messageboard.ListOptions{
+			Page:   1,
+			Status: []messageboard.Status{messageboard.StatusApproved},
+		}).
+		Return(&messageboard.MessageList{Data: []*messageboard.Message{}}, nil)
+
+	opts := &messageboard.ListOptions{
+		Page:   1,
+		Status: []messageboard.Status{messageboard.StatusPending},
+	}
+	svc := messageboard.NewService(storage)
+	_, err := svc.List(context.Background(), opts)
+	assert.NoError(t, err)
+	// The options of the caller are kept.
+	assert.Equal(t, []messageboard.Status{messageboard.StatusPending}, opts.Status)
+}
+
+func TestService_Moderate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expMsg := &messageboard.Message{
+		ID:           "my-id",
+		Status:       messageboard.StatusRejected,
+		StatusReason: "Spam",
+		ModeratedBy:  "moderator",
+	}
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		Moderate(gomock.Any(), "my-id", &messageboard.Moderation{Status: messageboard.StatusRejected, Reason: "Spam"}, "moderator").
+		Return(nil)
+	storage.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(expMsg, nil)
+
+	ctx := messageboard.ContextWithUser(context.Background(), "moderator")
+
+	svc := messageboard.NewService(storage)
+	msg, err := svc.Moderate(ctx, "my-id", &messageboard.Moderation{Status: messageboard.StatusRejected, Reason: " Spam "})
+	assert.NoError(t, err)
+	assert.Equal(t, expMsg, msg)
+
+	_, err = svc.Moderate(ctx, "my-id", &messageboard.Moderation{Status: messageboard.StatusRejected})
+	var errs messageboard.FieldErrors
+	errs.Add("reason", "missing_reason", `field "reason" is missing`)
+	assert.Equal(t, errs.Err(), err)
+}
+
+func TestService_CreateFiltered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	spam := messageboard.ContentFilterFunc(func(ctx context.Context, msg *messageboard.Message) (messageboard.FilterResult, error) {
+		if msg.Text == "Free money" {
+			return messageboard.Flag("looks like spam"), nil
+		}
+		return messageboard.Accept(), nil
+	})
+	banned := messageboard.ContentFilterFunc(func(ctx context.Context, msg *messageboard.Message) (messageboard.FilterResult, error) {
+		if msg.Name == "Spammer" {
+			return messageboard.Reject("spammer is banned"), nil
+		}
+		return messageboard.Accept(), nil
+	})
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, msg *messageboard.Message) error {
+			// Flagged even when posted by an user.
+			assert.Equal(t, messageboard.StatusFlagged, msg.Status)
+			assert.Equal(t, "looks like spam", msg.StatusReason)
+			msg.ID = "my-id"
+			return nil
+		})
+	storage.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{ID: "my-id"}, nil)
+
+	ctx := messageboard.ContextWithUser(context.Background(), "moderator")
+
+	svc := messageboard.NewService(storage, messageboard.WithContentFilters(spam, banned))
+	_, err := svc.Create(ctx, &messageboard.Message{
+		Name:  "Guilherme",
+		Email: "xguiga@gmail.com",
+		Text:  "Free money",
+	})
+	assert.NoError(t, err)
+
+	// Rejected even after being flagged by a previous filter.
+	_, err = svc.Create(ctx, &messageboard.Message{
+		Name:  "Spammer",
+		Email: "spammer@example.com",
+		Text:  "Free money",
+	})
+	storagetest.AssertErrorCode(t, "content_rejected", err)
+}
+
+func TestService_UpdateFiltered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	spam := messageboard.ContentFilterFunc(func(ctx context.Context, msg *messageboard.Message) (messageboard.FilterResult, error) {
+		return messageboard.Flag("looks like spam"), nil
+	})
+	reqMsg := &messageboard.Message{
+		ID:    "my-id",
+		Name:  "Guilherme",
+		Email: "xguiga@gmail.com",
+		Text:  "Free money",
+	}
+
+	storage := mock.NewStorage(ctrl)
+	gomock.InOrder(
+		// The status is saved with the content.
+		storage.EXPECT().
+			Update(gomock.Any(), &messageboard.Message{
+				ID:           "my-id",
+				Name:         "Guilherme",
+				Email:        "xguiga@gmail.com",
+				Text:         "Free money",
+				Status:       messageboard.StatusFlagged,
+				StatusReason: "looks like spam",
+			}, "moderator").
+			Return(nil),
+		storage.EXPECT().
+			Get(gomock.Any(), "my-id").
+			Return(&messageboard.Message{ID: "my-id", Status: messageboard.StatusFlagged}, nil),
+	)
+
+	ctx := messageboard.ContextWithUser(context.Background(), "moderator")
+
+	svc := messageboard.NewService(storage, messageboard.WithContentFilters(spam))
+	msg, err := svc.Update(ctx, reqMsg)
+	assert.NoError(t, err)
+	assert.Equal(t, messageboard.StatusFlagged, msg.Status)
+}
+
+func TestService_UpdateRejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	failing := messageboard.ContentFilterFunc(func(ctx context.Context, msg *messageboard.Message) (messageboard.FilterResult, error) {
+		return messageboard.Reject("not allowed"), nil
+	})
+
+	storage := mock.NewStorage(ctrl)
+
+	svc := messageboard.NewService(storage, messageboard.WithContentFilters(failing))
+	_, err := svc.Update(context.Background(), &messageboard.Message{
+		ID:    "my-id",
+		Name:  "Guilherme",
+		Email: "xguiga@gmail.com",
+		Text:  "My text",
+	})
+	storagetest.AssertErrorCode(t, "content_rejected", err)
+}
+
+func TestService_CreateDuplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqMsg := &messageboard.Message{
+		Name:  "Guilherme",
+		Email: "xguiga@gmail.com",
+		Text:  "My long message",
+	}
+	dup := &messageboard.Message{
+		ID:      "other-id",
+		BoardID: messageboard.DefaultBoardID,
+		Name:    "Guilherme",
+		Email:   "xguiga@gmail.com",
+		Text:    "My long message",
+	}
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		FindDuplicate(gomock.Any(), reqMsg, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, msg *messageboard.Message, since time.Time) (*messageboard.Message, error) {
+			assert.WithinDuration(t, time.Now().Add(-10*time.Minute), since, time.Second)
+			return dup, nil
+		}).
+		Times(2)
+
+	ctx := context.Background()
+
+	svc := messageboard.NewService(storage, messageboard.WithDuplicateDetection(10*time.Minute, messageboard.DuplicateReject))
+	_, err := svc.Create(ctx, reqMsg)
+	var mberr *messageboard.Error
+	if assert.True(t, errors.As(err, &mberr), "expected *messageboard.Error, got: %v", err) {
+		assert.Equal(t, messageboard.CategoryConflict, mberr.Category)
+		assert.Equal(t, "duplicate", mberr.Code)
+		assert.Equal(t, "other-id", mberr.ID)
+	}
+
+	svc = messageboard.NewService(storage, messageboard.WithDuplicateDetection(10*time.Minute, messageboard.DuplicateMerge))
+	msg, err := svc.Create(ctx, reqMsg)
+	assert.NoError(t, err)
+	assert.Equal(t, dup, msg)
+	assert.True(t, msg.Merged)
+}
+
+func TestService_CreateDuplicateHidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		FindDuplicate(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&messageboard.Message{
+			ID:           "other-id",
+			BoardID:      messageboard.DefaultBoardID,
+			Name:         "Guilherme",
+			Email:        "xguiga@gmail.com",
+			Text:         "My long message",
+			Status:       messageboard.StatusRejected,
+			StatusReason: "Spam",
+			ModeratedBy:  "moderator",
+		}, nil)
+
+	svc := messageboard.NewService(storage, messageboard.WithDuplicateDetection(10*time.Minute, messageboard.DuplicateMerge))
+	msg, err := svc.Create(context.Background(), &messageboard.Message{
+		Name:  "Guilherme",
+		Email: "xguiga@gmail.com",
+		Text:  "My long message",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &messageboard.Message{ID: "other-id", Merged: true}, msg)
+}
+
+func TestService_CreateNotDuplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mock.NewStorage(ctrl)
+	storage.EXPECT().
+		FindDuplicate(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, messageboard.NewNotFoundError("not_found", "message was not found"))
+	storage.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, msg *messageboard.Message) error {
+			msg.ID = "my-id"
+			return nil
+		})
+	storage.EXPECT().
+		Get(gomock.Any(), "my-id").
+		Return(&messageboard.Message{ID: "my-id"}, nil)
+
+	svc := messageboard.NewService(storage, messageboard.WithDuplicateDetection(time.Minute, messageboard.DuplicateReject))
+	msg, err := svc.Create(context.Background(), &messageboard.Message{
+		Name:  "Guilherme",
+		Email: "xguiga@gmail.com",
+		Text:  "My long message",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "my-id", msg.ID)
+}
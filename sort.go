@@ -0,0 +1,110 @@
+package messageboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortField is a field used to sort the messages.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Sort is the order of the messages, ties are always broken by the id, in the
+// same direction of the first field.
+type Sort []SortField
+
+// DefaultSort is used when no sort is specified, newest messages first.
+var DefaultSort = Sort{{Field: "creation_time", Desc: true}}
+
+// sortableFields has the fields allowed to be used in Sort and how to compare them.
+var sortableFields = map[string]func(a, b *Message) int{
+	"creation_time": func(a, b *Message) int {
+		switch {
+		case a.CreationTime.Before(b.CreationTime):
+			return -1
+		case a.CreationTime.After(b.CreationTime):
+			return 1
+		}
+		return 0
+	},
+	"name": func(a, b *Message) int {
+		return strings.Compare(a.Name, b.Name)
+	},
+	"email": func(a, b *Message) int {
+		return strings.Compare(a.Email, b.Email)
+	},
+}
+
+// ParseSort parses a comma separated list of fields, fields prefixed with "-"
+// are sorted in descending order, e.g. "creation_time,-name,email".
+func ParseSort(s string) (Sort, error) {
+	var srt Sort
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+
+		var desc bool
+		if strings.HasPrefix(field, "-") {
+			desc = true
+			field = field[1:]
+		}
+		if _, ok := sortableFields[field]; !ok || seen[field] {
+			return nil, NewValidationError("invalid_sort", fmt.Sprintf(
+				"sort must be a comma separated list of unique fields (prefixed with - for descending order), allowed fields are: %s",
+				strings.Join(SortableFields(), ", "),
+			))
+		}
+		seen[field] = true
+
+		srt = append(srt, SortField{
+			Field: field,
+			Desc:  desc,
+		})
+	}
+	return srt, nil
+}
+
+// SortableFields returns the fields allowed to be used in Sort.
+func SortableFields() []string {
+	fields := make([]string, 0, len(sortableFields))
+	for field := range sortableFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// String returns the sort in the format accepted by ParseSort.
+func (srt Sort) String() string {
+	fields := make([]string, len(srt))
+	for i, f := range srt {
+		fields[i] = f.Field
+		if f.Desc {
+			fields[i] = "-" + f.Field
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// Compare returns a negative number when a comes before b, a positive number
+// when a comes after b, and zero if they are the same message.
+func (srt Sort) Compare(a, b *Message) int {
+	for _, f := range srt {
+		cmp := sortableFields[f.Field](a, b)
+		if f.Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+
+	cmp := strings.Compare(a.ID, b.ID)
+	if len(srt) > 0 && srt[0].Desc {
+		cmp = -cmp
+	}
+	return cmp
+}
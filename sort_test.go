@@ -0,0 +1,46 @@
+package messageboard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSort(t *testing.T) {
+	srt, err := messageboard.ParseSort("creation_time, -name,email")
+	require.NoError(t, err)
+	assert.Equal(t, messageboard.Sort{
+		{Field: "creation_time"},
+		{Field: "name", Desc: true},
+		{Field: "email"},
+	}, srt)
+	assert.Equal(t, "creation_time,-name,email", srt.String())
+}
+
+func TestParseSortInvalid(t *testing.T) {
+	for _, s := range []string{"text", "-id", "name,-name", "name,", "--name"} {
+		_, err := messageboard.ParseSort(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSort_Compare(t *testing.T) {
+	now := time.Now()
+	a := &messageboard.Message{ID: "1", Name: "Alice", CreationTime: now}
+	b := &messageboard.Message{ID: "2", Name: "Bob", CreationTime: now.Add(-time.Hour)}
+	c := &messageboard.Message{ID: "3", Name: "Bob", CreationTime: now.Add(-time.Hour)}
+
+	assert.True(t, messageboard.DefaultSort.Compare(a, b) < 0)
+	assert.True(t, messageboard.DefaultSort.Compare(b, a) > 0)
+	// Ties are broken by the id, in the direction of the first field.
+	assert.True(t, messageboard.DefaultSort.Compare(c, b) < 0)
+
+	srt := messageboard.Sort{{Field: "name"}, {Field: "creation_time"}}
+	assert.True(t, srt.Compare(a, b) < 0)
+	assert.True(t, srt.Compare(b, c) < 0)
+	assert.Equal(t, 0, srt.Compare(b, b))
+}
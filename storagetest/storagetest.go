@@ -0,0 +1,1463 @@
+// Package storagetest provides a conformance test suite for messageboard.Storage
+// implementations, every implementation should pass on it to behave the same way.
+//
+// Usage:
+//
+//	func TestMessageBoardStorage(t *testing.T) {
+//		storagetest.Run(t, func() messageboard.Storage {
+//			return mystorage.NewMessageBoardStorage()
+//		})
+//	}
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs the whole suite against the storages returned by newStorage, it's
+// called once per test and should return an empty storage.
+func Run(t *testing.T, newStorage func() messageboard.Storage) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, messageboard.Storage)
+	}{
+		{"Create", testCreate},
+		{"CreateServerFields", testCreateServerFields},
+		{"GetNotFound", testGetNotFound},
+		{"List", testList},
+		{"ListEmpty", testListEmpty},
+		{"ListCursor", testListCursor},
+		{"ListFilters", testListFilters},
+		{"ListQuery", testListQuery},
+		{"ListSort", testListSort},
+		{"ListBoard", testListBoard},
+		{"ListTags", testListTags},
+		{"Tags", testTags},
+		{"Moderate", testModerate},
+		{"FindDuplicate", testFindDuplicate},
+		{"Replies", testReplies},
+		{"Update", testUpdate},
+		{"UpdateStatus", testUpdateStatus},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"UpdateVersion", testUpdateVersion},
+		{"Revisions", testRevisions},
+		{"Delete", testDelete},
+		{"DeleteNotFound", testDeleteNotFound},
+		{"Restore", testRestore},
+		{"RestoreNotDeleted", testRestoreNotDeleted},
+		{"Boards", testBoards},
+		{"BoardNotFound", testBoardNotFound},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStorage())
+		})
+	}
+}
+
+// AssertErrorCode asserts that err is a *messageboard.Error with the given code.
+func AssertErrorCode(t *testing.T, code string, err error) bool {
+	t.Helper()
+
+	var mberr *messageboard.Error
+	if !errors.As(err, &mberr) {
+		return assert.Fail(t, fmt.Sprintf("expected *messageboard.Error with code %q, got: %v", code, err))
+	}
+	return assert.Equal(t, code, mberr.Code)
+}
+
+func newMessage(i int) *messageboard.Message {
+	return &messageboard.Message{
+		Name:  fmt.Sprintf("Name %d", i),
+		Email: fmt.Sprintf("email%d@example.com", i),
+		Text:  fmt.Sprintf("Text of message %d", i),
+	}
+}
+
+func testCreate(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	before := time.Now().UTC().Add(-time.Second)
+	msg := newMessage(1)
+	err := s.Create(ctx, msg)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, msg.ID)
+	assert.True(t, msg.CreationTime.After(before), "creation_time was not set")
+	assert.True(t, msg.CreationTime.Before(time.Now().UTC().Add(time.Second)), "creation_time is in the future")
+
+	got, err := s.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, msg.ID, got.ID)
+	assert.Equal(t, msg.Name, got.Name)
+	assert.Equal(t, msg.Email, got.Email)
+	assert.Equal(t, msg.Text, got.Text)
+	assert.True(t, msg.CreationTime.Equal(got.CreationTime), "expected creation_time %v, got %v", msg.CreationTime, got.CreationTime)
+	assert.Equal(t, int64(1), msg.Version)
+	assert.Equal(t, int64(1), got.Version)
+	assert.Nil(t, got.UpdateTime)
+	assert.Empty(t, got.UpdatedBy)
+
+	// A second message should receive a different id.
+	other := newMessage(2)
+	err = s.Create(ctx, other)
+	require.NoError(t, err)
+	assert.NotEqual(t, msg.ID, other.ID)
+}
+
+func testCreateServerFields(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	msg := newMessage(1)
+	msg.UpdateTime = &now
+	msg.UpdatedBy = "alice"
+	msg.DeletionTime = &now
+	msg.DeletedBy = "alice"
+	msg.ModerationTime = &now
+	msg.ModeratedBy = "alice"
+	msg.ReplyCount = 10
+	msg.Score = 1.5
+	err := s.Create(ctx, msg)
+	require.NoError(t, err)
+
+	// The message is not in the trash.
+	got, err := s.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.UpdateTime)
+	assert.Empty(t, got.UpdatedBy)
+	assert.Nil(t, got.DeletionTime)
+	assert.Empty(t, got.DeletedBy)
+	assert.Nil(t, got.ModerationTime)
+	assert.Empty(t, got.ModeratedBy)
+	assert.Zero(t, got.ReplyCount)
+	assert.Zero(t, got.Score)
+}
+
+func testGetNotFound(t *testing.T, s messageboard.Storage) {
+	_, err := s.Get(context.Background(), "does-not-exist")
+	AssertErrorCode(t, "not_found", err)
+}
+
+func testList(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	const total = 7
+	ids := make(map[string]bool)
+	for i := 0; i < total; i++ {
+		msg := newMessage(i)
+		err := s.Create(ctx, msg)
+		require.NoError(t, err)
+		ids[msg.ID] = true
+		// Make sure messages don't share the same creation_time.
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	var all []*messageboard.Message
+	for page := uint(1); page <= 3; page++ {
+		list, err := s.List(ctx, &messageboard.ListOptions{
+			PerPage: 3,
+			Page:    page,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, uint(total), list.Total)
+
+		expLen := 3
+		if page == 3 {
+			expLen = 1
+		}
+		assert.Len(t, list.Data, expLen, "page %d", page)
+		all = append(all, list.Data...)
+	}
+
+	require.Len(t, all, total)
+	for i, msg := range all {
+		assert.True(t, ids[msg.ID], "unexpected message %q", msg.ID)
+		delete(ids, msg.ID)
+
+		if i > 0 {
+			assert.True(t, all[i-1].CreationTime.After(msg.CreationTime),
+				"messages are not sorted newest first: %v before %v", all[i-1].CreationTime, msg.CreationTime)
+		}
+	}
+	assert.Empty(t, ids, "messages missing from the list")
+
+	// Pages after the last one are empty.
+	list, err := s.List(ctx, &messageboard.ListOptions{
+		PerPage: 3,
+		Page:    4,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(total), list.Total)
+	assert.NotNil(t, list.Data)
+	assert.Empty(t, list.Data)
+}
+
+func testListEmpty(t *testing.T, s messageboard.Storage) {
+	list, err := s.List(context.Background(), &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), list.Total)
+	assert.NotNil(t, list.Data)
+	assert.Empty(t, list.Data)
+}
+
+func testListCursor(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	const total = 7
+	for i := 0; i < total; i++ {
+		err := s.Create(ctx, newMessage(i))
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	all, err := s.List(ctx, &messageboard.ListOptions{
+		PerPage: total,
+		Page:    1,
+	})
+	require.NoError(t, err)
+	require.Len(t, all.Data, total)
+	assert.Empty(t, all.Next)
+	assert.Empty(t, all.Prev)
+
+	ids := func(msgs []*messageboard.Message) []string {
+		ids := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			ids = append(ids, msg.ID)
+		}
+		return ids
+	}
+	list := func(cursor string) *messageboard.MessageList {
+		t.Helper()
+
+		opts := &messageboard.ListOptions{PerPage: 3, Page: 1}
+		if cursor != "" {
+			opts.Cursor, err = messageboard.ParseCursor(cursor)
+			require.NoError(t, err)
+		}
+		list, err := s.List(ctx, opts)
+		require.NoError(t, err)
+		return list
+	}
+
+	// Walk forward, starting from the first page.
+	page1 := list("")
+	assert.Equal(t, uint(total), page1.Total)
+	assert.Equal(t, ids(all.Data[0:3]), ids(page1.Data))
+	assert.Empty(t, page1.Prev)
+	require.NotEmpty(t, page1.Next)
+
+	page2 := list(page1.Next)
+	assert.Equal(t, ids(all.Data[3:6]), ids(page2.Data))
+	assert.NotEmpty(t, page2.Prev)
+	require.NotEmpty(t, page2.Next)
+
+	page3 := list(page2.Next)
+	assert.Equal(t, ids(all.Data[6:7]), ids(page3.Data))
+	assert.Empty(t, page3.Next)
+	require.NotEmpty(t, page3.Prev)
+
+	// Walk backward, starting from the last page.
+	prev := list(page3.Prev)
+	assert.Equal(t, ids(all.Data[3:6]), ids(prev.Data))
+	assert.NotEmpty(t, prev.Next)
+	require.NotEmpty(t, prev.Prev)
+
+	prev = list(prev.Prev)
+	assert.Equal(t, ids(all.Data[0:3]), ids(prev.Data))
+	assert.Empty(t, prev.Prev)
+	assert.NotEmpty(t, prev.Next)
+
+	// New messages don't shift the pages.
+	err = s.Create(ctx, newMessage(total))
+	require.NoError(t, err)
+
+	page2 = list(page1.Next)
+	assert.Equal(t, ids(all.Data[3:6]), ids(page2.Data))
+
+	// Offset pages also return cursors.
+	offset, err := s.List(ctx, &messageboard.ListOptions{PerPage: 3, Page: 2})
+	require.NoError(t, err)
+	assert.NotEmpty(t, offset.Prev)
+	assert.NotEmpty(t, offset.Next)
+}
+
+func testListFilters(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	create := func(name, email string) *messageboard.Message {
+		msg := &messageboard.Message{
+			Name:  name,
+			Email: email,
+			Text:  "Some text",
+		}
+		err := s.Create(ctx, msg)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		return msg
+	}
+	a := create("Alice", "alice@example.com")
+	b := create("Alice", "bob@example.com")
+	c := create("Bob", "bob@example.com")
+
+	tests := []struct {
+		name string
+		opts messageboard.ListOptions
+		exp  []*messageboard.Message
+	}{
+		{"name", messageboard.ListOptions{Name: "Alice"}, []*messageboard.Message{b, a}},
+		{"email", messageboard.ListOptions{Email: "bob@example.com"}, []*messageboard.Message{c, b}},
+		{"name and email", messageboard.ListOptions{Name: "Alice", Email: "bob@example.com"}, []*messageboard.Message{b}},
+		{"created after", messageboard.ListOptions{CreatedAfter: a.CreationTime}, []*messageboard.Message{c, b}},
+		{"created before", messageboard.ListOptions{CreatedBefore: c.CreationTime}, []*messageboard.Message{b, a}},
+		{"created between", messageboard.ListOptions{CreatedAfter: a.CreationTime, CreatedBefore: c.CreationTime}, []*messageboard.Message{b}},
+		{"no match", messageboard.ListOptions{Name: "Carol"}, []*messageboard.Message{}},
+	}
+	for _, tt := range tests {
+		opts := tt.opts
+		opts.PerPage = 1
+		opts.Page = 1
+
+		list, err := s.List(ctx, &opts)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, uint(len(tt.exp)), list.Total, tt.name)
+		if len(tt.exp) == 0 {
+			assert.Empty(t, list.Data, tt.name)
+			continue
+		}
+		if assert.Len(t, list.Data, 1, tt.name) {
+			assert.Equal(t, tt.exp[0].ID, list.Data[0].ID, tt.name)
+		}
+
+		// Cursors keep the filters.
+		if len(tt.exp) > 1 {
+			require.NotEmpty(t, list.Next, tt.name)
+			opts.Cursor, err = messageboard.ParseCursor(list.Next)
+			require.NoError(t, err)
+
+			list, err = s.List(ctx, &opts)
+			require.NoError(t, err, tt.name)
+			if assert.Len(t, list.Data, 1, tt.name) {
+				assert.Equal(t, tt.exp[1].ID, list.Data[0].ID, tt.name)
+			}
+			assert.Empty(t, list.Next, tt.name)
+		}
+	}
+}
+
+func testListQuery(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	create := func(name, text string) *messageboard.Message {
+		msg := &messageboard.Message{
+			Name:  name,
+			Email: "email@example.com",
+			Text:  text,
+		}
+		err := s.Create(ctx, msg)
+		require.NoError(t, err)
+		return msg
+	}
+	best := create("Golang", "golang golang golang")
+	good := create("Alice", "I wrote some code in golang yesterday")
+	create("Bob", "I like coffee")
+	deleted := create("Carol", "golang is nice")
+	err := s.Delete(ctx, deleted.ID, "moderator")
+	require.NoError(t, err)
+
+	list, err := s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+		Query:   "golang",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), list.Total)
+	if assert.Len(t, list.Data, 2) {
+		assert.Equal(t, best.ID, list.Data[0].ID, "most relevant message should be the first")
+		assert.Equal(t, good.ID, list.Data[1].ID)
+		assert.True(t, list.Data[0].Score > list.Data[1].Score, "expected score %v > %v", list.Data[0].Score, list.Data[1].Score)
+		assert.True(t, list.Data[1].Score > 0, "score was not set")
+	}
+	// Lists sorted by relevance have no cursors.
+	assert.Empty(t, list.Next)
+	assert.Empty(t, list.Prev)
+
+	// Searching together with filters.
+	list, err = s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+		Query:   "golang",
+		Name:    "Alice",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), list.Total)
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, good.ID, list.Data[0].ID)
+	}
+
+	// Updated messages are searchable with the new text.
+	err = s.Update(ctx, &messageboard.Message{
+		ID:    good.ID,
+		Name:  good.Name,
+		Email: good.Email,
+		Text:  "I prefer tea",
+	}, "moderator")
+	require.NoError(t, err)
+
+	list, err = s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+		Query:   "tea",
+	})
+	require.NoError(t, err)
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, good.ID, list.Data[0].ID)
+	}
+
+	_, err = s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Query:   "golang",
+		Cursor:  messageboard.NewCursor(best, messageboard.DefaultSort, false),
+	})
+	AssertErrorCode(t, "cursor_not_supported", err)
+}
+
+func testListSort(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	// Names repeat, so the second field is needed to break ties.
+	msgs := []*messageboard.Message{
+		{Name: "Bob", Email: "b@example.com", Text: "Golang is great"},
+		{Name: "Alice", Email: "a@example.com", Text: "Golang is fun"},
+		{Name: "Bob", Email: "c@example.com", Text: "Rust is great"},
+		{Name: "Alice", Email: "d@example.com", Text: "Golang is simple"},
+		{Name: "Carol", Email: "e@example.com", Text: "Golang is fast"},
+	}
+	for _, msg := range msgs {
+		err := s.Create(ctx, msg)
+		require.NoError(t, err)
+	}
+	emails := func(msgs []*messageboard.Message) []string {
+		emails := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			emails = append(emails, msg.Email)
+		}
+		return emails
+	}
+
+	srt, err := messageboard.ParseSort("name,-email")
+	require.NoError(t, err)
+
+	list, err := s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+		Sort:    srt,
+	})
+	require.NoError(t, err)
+	want := []string{"d@example.com", "a@example.com", "c@example.com", "b@example.com", "e@example.com"}
+	assert.Equal(t, want, emails(list.Data))
+
+	// Walk forward and backward with cursors.
+	var got []string
+	opts := &messageboard.ListOptions{PerPage: 2, Page: 1, Sort: srt}
+	for {
+		list, err := s.List(ctx, opts)
+		require.NoError(t, err)
+		got = append(got, emails(list.Data)...)
+		if list.Next == "" {
+			break
+		}
+		opts.Cursor, err = messageboard.ParseCursor(list.Next)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, want, got)
+
+	opts.Cursor = messageboard.NewCursor(list.Data[3], srt, true)
+	list, err = s.List(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, want[1:3], emails(list.Data))
+	assert.NotEmpty(t, list.Prev)
+	assert.NotEmpty(t, list.Next)
+
+	// Cursors only work with the sort used to create them.
+	_, err = s.List(ctx, &messageboard.ListOptions{
+		PerPage: 2,
+		Cursor:  opts.Cursor,
+	})
+	AssertErrorCode(t, "invalid_cursor", err)
+
+	// Full-text search sorted by a field instead of relevance.
+	srt, err = messageboard.ParseSort("-email")
+	require.NoError(t, err)
+	list, err = s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+		Query:   "golang",
+		Sort:    srt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e@example.com", "d@example.com", "b@example.com", "a@example.com"}, emails(list.Data))
+	assert.Empty(t, list.Next)
+}
+
+func testReplies(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	parent := newMessage(1)
+	err := s.Create(ctx, parent)
+	require.NoError(t, err)
+	err = s.Create(ctx, newMessage(2))
+	require.NoError(t, err)
+
+	var replies []*messageboard.Message
+	for i := 0; i < 3; i++ {
+		reply := newMessage(10 + i)
+		reply.ParentID = parent.ID
+		reply.ReplyCount = 10 // It's ignored.
+		err := s.Create(ctx, reply)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), reply.ReplyCount)
+		replies = append(replies, reply)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	replyCount := func(id string) int64 {
+		t.Helper()
+
+		msg, err := s.Get(ctx, id)
+		require.NoError(t, err)
+		return msg.ReplyCount
+	}
+	assert.Equal(t, int64(3), replyCount(parent.ID))
+
+	got, err := s.Get(ctx, replies[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, parent.ID, got.ParentID)
+
+	list, err := s.List(ctx, &messageboard.ListOptions{
+		PerPage:  2,
+		Page:     1,
+		Sort:     messageboard.Sort{{Field: "creation_time"}},
+		ParentID: parent.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), list.Total)
+	if assert.Len(t, list.Data, 2) {
+		assert.Equal(t, replies[0].ID, list.Data[0].ID)
+		assert.Equal(t, replies[1].ID, list.Data[1].ID)
+	}
+
+	// Replies in the trash are not counted.
+	err = s.Delete(ctx, replies[0].ID, "moderator")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), replyCount(parent.ID))
+
+	err = s.Restore(ctx, messageboard.DefaultBoardID, replies[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), replyCount(parent.ID))
+
+	// Updates don't change the counter.
+	err = s.Update(ctx, &messageboard.Message{
+		ID:         parent.ID,
+		Name:       parent.Name,
+		Email:      parent.Email,
+		Text:       "New text",
+		ReplyCount: 10,
+	}, "moderator")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), replyCount(parent.ID))
+}
+
+func testUpdate(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	msg := newMessage(1)
+	err := s.Create(ctx, msg)
+	require.NoError(t, err)
+	other := newMessage(2)
+	err = s.Create(ctx, other)
+	require.NoError(t, err)
+
+	err = s.Update(ctx, &messageboard.Message{
+		ID:           msg.ID,
+		Name:         "New name",
+		Email:        "new@example.com",
+		Text:         "New text",
+		CreationTime: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}, "moderator")
+	require.NoError(t, err)
+
+	got, err := s.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, msg.ID, got.ID)
+	assert.Equal(t, "New name", got.Name)
+	assert.Equal(t, "new@example.com", got.Email)
+	assert.Equal(t, "New text", got.Text)
+	assert.True(t, msg.CreationTime.Equal(got.CreationTime), "creation_time should not be updated")
+	assert.Equal(t, int64(2), got.Version)
+	if assert.NotNil(t, got.UpdateTime) {
+		assert.False(t, got.UpdateTime.Before(msg.CreationTime), "update_time is before creation_time")
+		assert.True(t, got.LastModified().Equal(*got.UpdateTime))
+	}
+	assert.Equal(t, "moderator", got.UpdatedBy)
+
+	// Other messages are untouched.
+	got, err = s.Get(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, other.Name, got.Name)
+	assert.Equal(t, other.Email, got.Email)
+	assert.Equal(t, other.Text, got.Text)
+}
+
+func testUpdateVersion(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	msg := newMessage(1)
+	err := s.Create(ctx, msg)
+	require.NoError(t, err)
+
+	// Two updates based on the same version, only the first one succeeds.
+	first := newMessage(2)
+	first.ID = msg.ID
+	first.Version = msg.Version
+	err = s.Update(ctx, first, "moderator")
+	require.NoError(t, err)
+
+	second := newMessage(3)
+	second.ID = msg.ID
+	second.Version = msg.Version
+	err = s.Update(ctx, second, "moderator")
+	AssertErrorCode(t, "version_conflict", err)
+	assert.Equal(t, messageboard.CategoryConflict, messageboard.ErrorCategoryOf(err))
+
+	got, err := s.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.Text, got.Text)
+	assert.Equal(t, int64(2), got.Version)
+
+	// Updating with the last version works.
+	second.Version = got.Version
+	err = s.Update(ctx, second, "moderator")
+	require.NoError(t, err)
+
+	got, err = s.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, second.Text, got.Text)
+	assert.Equal(t, int64(3), got.Version)
+
+	// A version of a message that doesn't exist is still not found.
+	second.ID = "does-not-exist"
+	err = s.Update(ctx, second, "moderator")
+	AssertErrorCode(t, "not_found", err)
+}
+
+func testRevisions(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	msg := newMessage(1)
+	err := s.Create(ctx, msg)
+	require.NoError(t, err)
+	other := newMessage(2)
+	err = s.Create(ctx, other)
+	require.NoError(t, err)
+
+	list, err := s.Revisions(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), list.Total)
+	assert.Empty(t, list.Data)
+
+	before := time.Now().UTC().Add(-time.Second)
+	for i, user := range []string{"alice", "bob"} {
+		upd := newMessage(10 + i)
+		upd.ID = msg.ID
+		err = s.Update(ctx, upd, user)
+		require.NoError(t, err)
+	}
+
+	list, err = s.Revisions(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), list.Total)
+	require.Len(t, list.Data, 2)
+
+	// Newest first, each one with the content replaced by the update.
+	assert.Equal(t, int64(2), list.Data[0].Version)
+	assert.Equal(t, newMessage(10).Text, list.Data[0].Text)
+	assert.Equal(t, "bob", list.Data[0].ChangedBy)
+	assert.Equal(t, int64(1), list.Data[1].Version)
+	assert.Equal(t, msg.Name, list.Data[1].Name)
+	assert.Equal(t, msg.Email, list.Data[1].Email)
+	assert.Equal(t, msg.Text, list.Data[1].Text)
+	assert.Equal(t, "alice", list.Data[1].ChangedBy)
+	for _, rev := range list.Data {
+		assert.Equal(t, msg.ID, rev.MessageID)
+		assert.True(t, rev.ChangeTime.After(before), "change_time was not set")
+	}
+
+	rev, err := s.Revision(ctx, msg.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, list.Data[1].Text, rev.Text)
+	assert.True(t, list.Data[1].ChangeTime.Equal(rev.ChangeTime))
+
+	// The current version is not a revision.
+	_, err = s.Revision(ctx, msg.ID, 3)
+	AssertErrorCode(t, "not_found", err)
+	_, err = s.Revision(ctx, other.ID, 1)
+	AssertErrorCode(t, "not_found", err)
+
+	// Updates rejected because of the version don't create revisions.
+	upd := newMessage(20)
+	upd.ID = msg.ID
+	upd.Version = 1
+	err = s.Update(ctx, upd, "carol")
+	AssertErrorCode(t, "version_conflict", err)
+
+	list, err = s.Revisions(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), list.Total)
+}
+
+func testUpdateStatus(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	msg := newMessage(1)
+	msg.Status = messageboard.StatusApproved
+	err := s.Create(ctx, msg)
+	require.NoError(t, err)
+
+	upd := newMessage(2)
+	upd.ID = msg.ID
+	upd.Status = messageboard.StatusFlagged
+	upd.StatusReason = "looks like spam"
+	err = s.Update(ctx, upd, "alice")
+	require.NoError(t, err)
+
+	got, err := s.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Text of message 2", got.Text)
+	assert.Equal(t, messageboard.StatusFlagged, got.Status)
+	assert.Equal(t, "looks like spam", got.StatusReason)
+	assert.NotNil(t, got.ModerationTime)
+	assert.Empty(t, got.ModeratedBy)
+
+	// Without status, the current one is kept.
+	upd = newMessage(3)
+	upd.ID = msg.ID
+	err = s.Update(ctx, upd, "alice")
+	require.NoError(t, err)
+
+	got, err = s.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Text of message 3", got.Text)
+	assert.Equal(t, messageboard.StatusFlagged, got.Status)
+	assert.Equal(t, "looks like spam", got.StatusReason)
+}
+
+func testUpdateNotFound(t *testing.T, s messageboard.Storage) {
+	err := s.Update(context.Background(), &messageboard.Message{
+		ID:    "does-not-exist",
+		Name:  "Name",
+		Email: "email@example.com",
+		Text:  "Text",
+	}, "moderator")
+	AssertErrorCode(t, "not_found", err)
+}
+
+func testDelete(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	msg := newMessage(1)
+	err := s.Create(ctx, msg)
+	require.NoError(t, err)
+	other := newMessage(2)
+	err = s.Create(ctx, other)
+	require.NoError(t, err)
+
+	before := time.Now().UTC().Add(-time.Second)
+	err = s.Delete(ctx, msg.ID, "moderator")
+	require.NoError(t, err)
+
+	// Deleted messages are hidden by default.
+	_, err = s.Get(ctx, msg.ID)
+	AssertErrorCode(t, "not_found", err)
+
+	err = s.Update(ctx, &messageboard.Message{
+		ID:    msg.ID,
+		Name:  "New name",
+		Email: "new@example.com",
+		Text:  "New text",
+	}, "moderator")
+	AssertErrorCode(t, "not_found", err)
+
+	list, err := s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), list.Total)
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, other.ID, list.Data[0].ID)
+	}
+
+	// But they're in the trash.
+	list, err = s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+		Deleted: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), list.Total)
+	if assert.Len(t, list.Data, 1) {
+		deleted := list.Data[0]
+		assert.Equal(t, msg.ID, deleted.ID)
+		assert.Equal(t, "moderator", deleted.DeletedBy)
+		if assert.NotNil(t, deleted.DeletionTime) {
+			assert.True(t, deleted.DeletionTime.After(before), "deletion_time was not set")
+		}
+	}
+
+	// Deleting it twice is not possible.
+	err = s.Delete(ctx, msg.ID, "moderator")
+	AssertErrorCode(t, "not_found", err)
+}
+
+func testDeleteNotFound(t *testing.T, s messageboard.Storage) {
+	err := s.Delete(context.Background(), "does-not-exist", "moderator")
+	AssertErrorCode(t, "not_found", err)
+}
+
+func testRestore(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	msg := newMessage(1)
+	err := s.Create(ctx, msg)
+	require.NoError(t, err)
+	err = s.Delete(ctx, msg.ID, "moderator")
+	require.NoError(t, err)
+
+	// Messages are not restored from other boards.
+	err = s.Restore(ctx, "golang", msg.ID)
+	AssertErrorCode(t, "not_found", err)
+	_, err = s.Get(ctx, msg.ID)
+	AssertErrorCode(t, "not_found", err)
+
+	err = s.Restore(ctx, messageboard.DefaultBoardID, msg.ID)
+	require.NoError(t, err)
+
+	got, err := s.Get(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.DeletionTime)
+	assert.Empty(t, got.DeletedBy)
+
+	list, err := s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+		Deleted: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), list.Total)
+}
+
+func testRestoreNotDeleted(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	msg := newMessage(1)
+	err := s.Create(ctx, msg)
+	require.NoError(t, err)
+
+	err = s.Restore(ctx, messageboard.DefaultBoardID, msg.ID)
+	AssertErrorCode(t, "not_found", err)
+
+	err = s.Restore(ctx, messageboard.DefaultBoardID, "does-not-exist")
+	AssertErrorCode(t, "not_found", err)
+}
+
+func testListBoard(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	create := func(boardID string) *messageboard.Message {
+		msg := newMessage(1)
+		msg.BoardID = boardID
+		err := s.Create(ctx, msg)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		return msg
+	}
+	// Messages created before boards existed don't have board, they are in the default one.
+	a := create("")
+	b := create(messageboard.DefaultBoardID)
+	c := create("golang")
+
+	ids := func(msgs []*messageboard.Message) []string {
+		ids := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			ids = append(ids, msg.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		boardID string
+		exp     []*messageboard.Message
+	}{
+		{"", []*messageboard.Message{c, b, a}},
+		{messageboard.DefaultBoardID, []*messageboard.Message{b, a}},
+		{"golang", []*messageboard.Message{c}},
+		{"art", []*messageboard.Message{}},
+	}
+	for _, tt := range tests {
+		list, err := s.List(ctx, &messageboard.ListOptions{
+			PerPage: 10,
+			Page:    1,
+			BoardID: tt.boardID,
+		})
+		require.NoError(t, err, tt.boardID)
+		assert.Equal(t, uint(len(tt.exp)), list.Total, tt.boardID)
+		assert.Equal(t, ids(tt.exp), ids(list.Data), tt.boardID)
+	}
+}
+
+func testListTags(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	create := func(tags ...string) *messageboard.Message {
+		msg := newMessage(1)
+		msg.Tags = tags
+		err := s.Create(ctx, msg)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		return msg
+	}
+	a := create("bug", "urgent")
+	b := create("bug")
+	c := create("crash", "urgent")
+	create()
+
+	tests := []struct {
+		name string
+		tags [][]string
+		exp  []*messageboard.Message
+	}{
+		{"one tag", [][]string{{"bug"}}, []*messageboard.Message{b, a}},
+		{"or", [][]string{{"bug", "crash"}}, []*messageboard.Message{c, b, a}},
+		{"and", [][]string{{"bug"}, {"urgent"}}, []*messageboard.Message{a}},
+		{"and or", [][]string{{"bug", "crash"}, {"urgent"}}, []*messageboard.Message{c, a}},
+		{"no match", [][]string{{"feature"}}, []*messageboard.Message{}},
+	}
+	for _, tt := range tests {
+		list, err := s.List(ctx, &messageboard.ListOptions{Tags: tt.tags})
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, uint(len(tt.exp)), list.Total, tt.name)
+		var ids, expIDs []string
+		for _, msg := range list.Data {
+			ids = append(ids, msg.ID)
+		}
+		for _, msg := range tt.exp {
+			expIDs = append(expIDs, msg.ID)
+		}
+		assert.Equal(t, expIDs, ids, tt.name)
+	}
+}
+
+func testTags(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	create := func(boardID string, tags ...string) *messageboard.Message {
+		msg := newMessage(1)
+		msg.BoardID = boardID
+		msg.Tags = tags
+		err := s.Create(ctx, msg)
+		require.NoError(t, err)
+		return msg
+	}
+	create("", "bug", "urgent")
+	create(messageboard.DefaultBoardID, "bug")
+	create("golang", "bug", "feature")
+	deleted := create("golang", "urgent")
+	err := s.Delete(ctx, deleted.ID, "moderator")
+	require.NoError(t, err)
+
+	// Updates change the tags counted.
+	upd := create("golang", "question")
+	upd.Tags = []string{"feature"}
+	err = s.Update(ctx, upd, "moderator")
+	require.NoError(t, err)
+	got, err := s.Get(ctx, upd.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feature"}, got.Tags)
+	revs, err := s.Revisions(ctx, upd.ID)
+	require.NoError(t, err)
+	if assert.Len(t, revs.Data, 1) {
+		assert.Equal(t, []string{"question"}, revs.Data[0].Tags)
+	}
+
+	list, err := s.Tags(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, &messageboard.TagList{
+		Total: 3,
+		Data: []*messageboard.TagCount{
+			{Tag: "bug", Count: 3},
+			{Tag: "feature", Count: 2},
+			{Tag: "urgent", Count: 1},
+		},
+	}, list)
+
+	list, err = s.Tags(ctx, messageboard.DefaultBoardID)
+	require.NoError(t, err)
+	assert.Equal(t, &messageboard.TagList{
+		Total: 2,
+		Data: []*messageboard.TagCount{
+			{Tag: "bug", Count: 2},
+			{Tag: "urgent", Count: 1},
+		},
+	}, list)
+
+	list, err = s.Tags(ctx, "art")
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), list.Total)
+	assert.Empty(t, list.Data)
+}
+
+func testModerate(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	create := func(status messageboard.Status) *messageboard.Message {
+		msg := newMessage(1)
+		msg.Status = status
+		err := s.Create(ctx, msg)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		return msg
+	}
+	// Messages created before moderation exist have no status, they are approved.
+	a := create("")
+	b := create(messageboard.StatusPending)
+	c := create(messageboard.StatusPending)
+
+	before := time.Now().UTC().Add(-time.Second)
+	err := s.Moderate(ctx, c.ID, &messageboard.Moderation{
+		Status: messageboard.StatusRejected,
+		Reason: "Spam",
+	}, "moderator")
+	require.NoError(t, err)
+
+	got, err := s.Get(ctx, c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, messageboard.StatusRejected, got.Status)
+	assert.Equal(t, "Spam", got.StatusReason)
+	assert.Equal(t, "moderator", got.ModeratedBy)
+	if assert.NotNil(t, got.ModerationTime) {
+		assert.True(t, got.ModerationTime.After(before), "moderation_time was not set")
+		assert.True(t, got.LastModified().Equal(*got.ModerationTime), "moderation didn't change last modified")
+	}
+	// Moderating is not an update.
+	assert.Equal(t, int64(1), got.Version)
+	assert.Nil(t, got.UpdateTime)
+
+	tests := []struct {
+		statuses []messageboard.Status
+		exp      []*messageboard.Message
+	}{
+		{nil, []*messageboard.Message{c, b, a}},
+		{[]messageboard.Status{messageboard.StatusApproved}, []*messageboard.Message{a}},
+		{[]messageboard.Status{messageboard.StatusPending, messageboard.StatusRejected}, []*messageboard.Message{c, b}},
+		{[]messageboard.Status{messageboard.StatusFlagged}, []*messageboard.Message{}},
+	}
+	for _, tt := range tests {
+		list, err := s.List(ctx, &messageboard.ListOptions{Status: tt.statuses})
+		require.NoError(t, err, "%v", tt.statuses)
+		var ids, expIDs []string
+		for _, msg := range list.Data {
+			ids = append(ids, msg.ID)
+		}
+		for _, msg := range tt.exp {
+			expIDs = append(expIDs, msg.ID)
+		}
+		assert.Equal(t, expIDs, ids, "%v", tt.statuses)
+	}
+
+	err = s.Moderate(ctx, "does-not-exist", &messageboard.Moderation{Status: messageboard.StatusApproved}, "moderator")
+	AssertErrorCode(t, "not_found", err)
+}
+
+func testBoards(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	list, err := s.ListBoards(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), list.Total)
+	assert.Empty(t, list.Data)
+
+	for _, id := range []string{"golang", "art"} {
+		err := s.CreateBoard(ctx, &messageboard.Board{
+			ID:         id,
+			Title:      "Board " + id,
+			Visibility: messageboard.VisibilityPublic,
+		})
+		require.NoError(t, err)
+	}
+	err = s.CreateBoard(ctx, &messageboard.Board{ID: "golang", Title: "Other"})
+	AssertErrorCode(t, "board_already_exists", err)
+
+	got, err := s.GetBoard(ctx, "golang")
+	require.NoError(t, err)
+	assert.Equal(t, "Board golang", got.Title)
+	assert.Equal(t, messageboard.VisibilityPublic, got.Visibility)
+	assert.False(t, got.CreationTime.IsZero(), "creation_time was not set")
+
+	err = s.UpdateBoard(ctx, &messageboard.Board{
+		ID:          "golang",
+		Title:       "Golang",
+		Description: "Everything about Go",
+		Visibility:  messageboard.VisibilityPrivate,
+	})
+	require.NoError(t, err)
+
+	updated, err := s.GetBoard(ctx, "golang")
+	require.NoError(t, err)
+	assert.Equal(t, "Golang", updated.Title)
+	assert.Equal(t, "Everything about Go", updated.Description)
+	assert.Equal(t, messageboard.VisibilityPrivate, updated.Visibility)
+	assert.True(t, got.CreationTime.Equal(updated.CreationTime), "creation_time was changed")
+
+	list, err = s.ListBoards(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), list.Total)
+	if assert.Len(t, list.Data, 2) {
+		assert.Equal(t, "art", list.Data[0].ID)
+		assert.Equal(t, "golang", list.Data[1].ID)
+	}
+
+	err = s.DeleteBoard(ctx, "golang")
+	require.NoError(t, err)
+	_, err = s.GetBoard(ctx, "golang")
+	AssertErrorCode(t, "not_found", err)
+}
+
+func testBoardNotFound(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	_, err := s.GetBoard(ctx, "does-not-exist")
+	AssertErrorCode(t, "not_found", err)
+
+	err = s.UpdateBoard(ctx, &messageboard.Board{ID: "does-not-exist", Title: "Title"})
+	AssertErrorCode(t, "not_found", err)
+
+	err = s.DeleteBoard(ctx, "does-not-exist")
+	AssertErrorCode(t, "not_found", err)
+}
+
+// TrashPurger is implemented by storages able to permanently remove messages from the trash.
+type TrashPurger interface {
+	messageboard.Storage
+	PurgeTrash(_ context.Context, before time.Time) (int64, error)
+}
+
+// RunPurgeTrash checks that only messages deleted before the given time are purged.
+func RunPurgeTrash(t *testing.T, s TrashPurger) {
+	ctx := context.Background()
+
+	msgs := make([]*messageboard.Message, 3)
+	for i := range msgs {
+		msgs[i] = newMessage(i)
+		err := s.Create(ctx, msgs[i])
+		require.NoError(t, err)
+	}
+	upd := newMessage(10)
+	upd.ID = msgs[0].ID
+	err := s.Update(ctx, upd, "moderator")
+	require.NoError(t, err)
+	err = s.Delete(ctx, msgs[0].ID, "moderator")
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	before := time.Now().UTC()
+	time.Sleep(2 * time.Millisecond)
+	err = s.Delete(ctx, msgs[1].ID, "moderator")
+	require.NoError(t, err)
+
+	n, err := s.PurgeTrash(ctx, before)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// Purged messages can't be restored anymore.
+	err = s.Restore(ctx, messageboard.DefaultBoardID, msgs[0].ID)
+	AssertErrorCode(t, "not_found", err)
+	revs, err := s.Revisions(ctx, msgs[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, revs.Data)
+
+	list, err := s.List(ctx, &messageboard.ListOptions{
+		PerPage: messageboard.DefaultPerPage,
+		Page:    1,
+		Deleted: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), list.Total)
+
+	_, err = s.Get(ctx, msgs[2].ID)
+	assert.NoError(t, err)
+}
+
+// CSVLoader is implemented by storages able to load the messages of a CSV file.
+type CSVLoader interface {
+	messageboard.Storage
+	LoadCSV(initialCSV string) error
+}
+
+// RunLoadCSV checks that the messages loaded from a CSV file are found as
+// duplicates, the same way as the ones created.
+func RunLoadCSV(t *testing.T, s CSVLoader) {
+	ctx := context.Background()
+
+	f, err := ioutil.TempFile("", "messages-*.csv")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("id,name,email,text,creation_time\n" +
+		"my-id,Name,name@example.com,Text,2017-12-14T06:20:33-08:00\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	err = s.LoadCSV(f.Name())
+	require.NoError(t, err)
+
+	dup, err := s.FindDuplicate(ctx, &messageboard.Message{
+		Name:  "Name",
+		Email: "name@example.com",
+		Text:  "Text",
+	}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "my-id", dup.ID)
+}
+
+// RunTenantIsolation checks that the data of a tenant is not reachable by the others.
+func RunTenantIsolation(t *testing.T, s messageboard.Storage) {
+	acme := messageboard.ContextWithTenant(context.Background(), "acme")
+	globex := messageboard.ContextWithTenant(context.Background(), "globex")
+	// The default tenant must not see the data of the other tenants either.
+	others := []context.Context{globex, context.Background()}
+
+	msg := newMessage(1)
+	msg.BoardID = "golang"
+	err := s.Create(acme, msg)
+	require.NoError(t, err)
+	reply := newMessage(2)
+	reply.ParentID = msg.ID
+	err = s.Create(acme, reply)
+	require.NoError(t, err)
+	upd := newMessage(3)
+	upd.ID = msg.ID
+	err = s.Update(acme, upd, "moderator")
+	require.NoError(t, err)
+	err = s.Delete(acme, reply.ID, "moderator")
+	require.NoError(t, err)
+	err = s.CreateBoard(acme, &messageboard.Board{ID: "golang", Title: "Golang"})
+	require.NoError(t, err)
+
+	for _, ctx := range others {
+		tenant := messageboard.TenantFromContext(ctx)
+
+		_, err := s.Get(ctx, msg.ID)
+		AssertErrorCode(t, "not_found", err)
+
+		for _, deleted := range []bool{false, true} {
+			list, err := s.List(ctx, &messageboard.ListOptions{Deleted: deleted})
+			require.NoError(t, err, tenant)
+			assert.Equal(t, uint(0), list.Total, tenant)
+			assert.Empty(t, list.Data, tenant)
+		}
+		list, err := s.List(ctx, &messageboard.ListOptions{Query: "message"})
+		require.NoError(t, err, tenant)
+		assert.Empty(t, list.Data, tenant)
+
+		revs, err := s.Revisions(ctx, msg.ID)
+		require.NoError(t, err, tenant)
+		assert.Empty(t, revs.Data, tenant)
+		_, err = s.Revision(ctx, msg.ID, 1)
+		AssertErrorCode(t, "not_found", err)
+
+		err = s.Update(ctx, upd, "intruder")
+		AssertErrorCode(t, "not_found", err)
+		err = s.Delete(ctx, msg.ID, "intruder")
+		AssertErrorCode(t, "not_found", err)
+		err = s.Restore(ctx, messageboard.DefaultBoardID, reply.ID)
+		AssertErrorCode(t, "not_found", err)
+
+		boards, err := s.ListBoards(ctx)
+		require.NoError(t, err, tenant)
+		assert.Empty(t, boards.Data, tenant)
+		_, err = s.GetBoard(ctx, "golang")
+		AssertErrorCode(t, "not_found", err)
+		err = s.DeleteBoard(ctx, "golang")
+		AssertErrorCode(t, "not_found", err)
+
+		// Replying to a message of another tenant doesn't touch it.
+		other := newMessage(4)
+		other.ParentID = msg.ID
+		err = s.Create(ctx, other)
+		require.NoError(t, err, tenant)
+	}
+
+	// Nothing was changed by the other tenants.
+	got, err := s.Get(acme, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, upd.Text, got.Text)
+	assert.Equal(t, int64(2), got.Version)
+	assert.Equal(t, int64(0), got.ReplyCount)
+
+	list, err := s.List(acme, &messageboard.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), list.Total)
+
+	_, err = s.GetBoard(acme, "golang")
+	assert.NoError(t, err)
+
+	// Invalid tenants never reach any data.
+	_, err = s.Get(messageboard.ContextWithTenant(context.Background(), "../acme"), msg.ID)
+	AssertErrorCode(t, "invalid_tenant", err)
+}
+
+func testFindDuplicate(t *testing.T, s messageboard.Storage) {
+	ctx := context.Background()
+
+	create := func(msg *messageboard.Message) *messageboard.Message {
+		err := s.Create(ctx, msg)
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		return msg
+	}
+	since := time.Now().UTC().Add(-time.Second)
+
+	parent := create(newMessage(1))
+	older := create(newMessage(2))
+	newer := create(newMessage(2))
+	reply := create(&messageboard.Message{ParentID: parent.ID, Name: "Name 2", Email: "email2@example.com", Text: "Text of message 2"})
+
+	// Case and spaces are ignored.
+	dup, err := s.FindDuplicate(ctx, &messageboard.Message{
+		BoardID: messageboard.DefaultBoardID,
+		Name:    "Other name",
+		Email:   "EMAIL2@example.com",
+		Text:    "text  of message\n2",
+	}, since)
+	require.NoError(t, err)
+	assert.Equal(t, newer.ID, dup.ID)
+
+	dup, err = s.FindDuplicate(ctx, &messageboard.Message{ParentID: parent.ID, Email: "email2@example.com", Text: "Text of message 2"}, since)
+	require.NoError(t, err)
+	assert.Equal(t, reply.ID, dup.ID)
+
+	// Messages in the trash are not duplicates.
+	err = s.Delete(ctx, newer.ID, "moderator")
+	require.NoError(t, err)
+	dup, err = s.FindDuplicate(ctx, newMessage(2), since)
+	require.NoError(t, err)
+	assert.Equal(t, older.ID, dup.ID)
+
+	// Updates change the content hash.
+	older.Text = "Updated text"
+	err = s.Update(ctx, older, "moderator")
+	require.NoError(t, err)
+	_, err = s.FindDuplicate(ctx, newMessage(2), since)
+	AssertErrorCode(t, "not_found", err)
+	dup, err = s.FindDuplicate(ctx, &messageboard.Message{Email: "email2@example.com", Text: "Updated text"}, since)
+	require.NoError(t, err)
+	assert.Equal(t, older.ID, dup.ID)
+
+	tests := []struct {
+		name  string
+		msg   *messageboard.Message
+		since time.Time
+	}{
+		{"other email", &messageboard.Message{Email: "email3@example.com", Text: "Text of message 1"}, since},
+		{"other board", &messageboard.Message{BoardID: "golang", Email: "email1@example.com", Text: "Text of message 1"}, since},
+		{"other parent", &messageboard.Message{ParentID: older.ID, Email: "email1@example.com", Text: "Text of message 1"}, since},
+		{"outside of the window", newMessage(1), time.Now().UTC().Add(time.Second)},
+	}
+	for _, tt := range tests {
+		_, err := s.FindDuplicate(ctx, tt.msg, tt.since)
+		AssertErrorCode(t, "not_found", err)
+	}
+}
+
+// RunIdempotencyStore checks the idempotency keys of s, which are kept apart
+// per tenant.
+func RunIdempotencyStore(t *testing.T, s messageboard.IdempotencyStore) {
+	ctx := context.Background()
+	acme := messageboard.ContextWithTenant(ctx, "acme")
+
+	key := &messageboard.IdempotencyKey{
+		Key:            "my-key",
+		RequestHash:    "hash",
+		ExpirationTime: time.Now().UTC().Add(time.Hour).Truncate(time.Millisecond),
+	}
+	saved, err := s.ReserveKey(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+
+	// The request is still running.
+	saved, err = s.ReserveKey(ctx, &messageboard.IdempotencyKey{
+		Key:            "my-key",
+		RequestHash:    "other-hash",
+		ExpirationTime: time.Now().UTC().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, "hash", saved.RequestHash)
+		assert.False(t, saved.Completed())
+	}
+
+	err = s.CompleteKey(ctx, "my-key", 201, []byte(`{"id":"my-id"}`))
+	require.NoError(t, err)
+
+	saved, err = s.ReserveKey(ctx, key)
+	require.NoError(t, err)
+	if assert.NotNil(t, saved) {
+		assert.True(t, saved.Completed())
+		assert.Equal(t, 201, saved.StatusCode)
+		assert.Equal(t, `{"id":"my-id"}`, string(saved.Body))
+		assert.True(t, key.ExpirationTime.Equal(saved.ExpirationTime), "expected expiration_time %v, got %v", key.ExpirationTime, saved.ExpirationTime)
+	}
+
+	// Other tenants have their own keys.
+	saved, err = s.ReserveKey(acme, key)
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+
+	err = s.ReleaseKey(acme, "my-key")
+	require.NoError(t, err)
+	saved, err = s.ReserveKey(acme, key)
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+
+	// Expired keys can be reserved again.
+	_, err = s.ReserveKey(ctx, &messageboard.IdempotencyKey{
+		Key:            "expired-key",
+		RequestHash:    "hash",
+		ExpirationTime: time.Now().UTC().Add(-time.Second),
+	})
+	require.NoError(t, err)
+	saved, err = s.ReserveKey(ctx, &messageboard.IdempotencyKey{
+		Key:            "expired-key",
+		RequestHash:    "other-hash",
+		ExpirationTime: time.Now().UTC().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+
+	err = s.CompleteKey(ctx, "does-not-exist", 201, nil)
+	AssertErrorCode(t, "not_found", err)
+}
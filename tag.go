@@ -0,0 +1,105 @@
+package messageboard
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxTags      = 10
+	MaxTagLength = 30
+)
+
+var tagRegexp = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{N}][\p{Ll}\p{Lo}\p{N}_-]*$`)
+
+// normalizeTags returns the tags trimmed, in lowercase and without duplicates,
+// keeping the order they were given.
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// validateTags normalizes and validates the tags of msg.
+func (msg *Message) validateTags(errs *FieldErrors) {
+	msg.Tags = normalizeTags(msg.Tags)
+	if len(msg.Tags) > MaxTags {
+		errs.Add("tags", "too_many_tags", fmt.Sprintf(`field "tags" must have at most %d tags`, MaxTags))
+		return
+	}
+	for _, tag := range msg.Tags {
+		switch {
+		case utf8.RuneCountInString(tag) > MaxTagLength:
+			errs.Add("tags", "tag_too_long", fmt.Sprintf(`field "tags" must have tags with at most %d characters`, MaxTagLength))
+			return
+		case !tagRegexp.MatchString(tag):
+			errs.Add("tags", "invalid_tag", fmt.Sprintf(`field "tags" has the invalid tag %q, tags must have only letters, digits, "-" and "_"`, tag))
+			return
+		}
+	}
+}
+
+// parseTagFilter parses the values of the tag query string, each value is a
+// comma separated list of tags where any of them must match (OR), and all
+// values must match (AND), e.g. tag=bug,crash&tag=urgent.
+func parseTagFilter(values []string) [][]string {
+	var filter [][]string
+	for _, v := range values {
+		var tags []string
+		for _, tag := range normalizeTags(strings.Split(v, ",")) {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) > 0 {
+			filter = append(filter, tags)
+		}
+	}
+	return filter
+}
+
+// MatchTags returns true if msg matches the tags filter of ListOptions.
+func (msg *Message) MatchTags(filter [][]string) bool {
+	for _, anyOf := range filter {
+		if !msg.hasAnyTag(anyOf) {
+			return false
+		}
+	}
+	return true
+}
+
+func (msg *Message) hasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		for _, t := range msg.Tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TagCount is how many messages have a tag.
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// TagList is the list of tags in use, most used first.
+type TagList struct {
+	Total uint        `json:"total"`
+	Data  []*TagCount `json:"data"`
+}
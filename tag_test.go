@@ -0,0 +1,88 @@
+package messageboard_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_ValidateTags(t *testing.T) {
+	msg := &messageboard.Message{
+		Name:  "Guilherme",
+		Email: "xguiga@gmail.com",
+		Text:  "My text",
+		Tags:  []string{" Bug", "crash_report", "bug", "Ação", "v2"},
+	}
+	err := msg.Validate()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bug", "crash_report", "ação", "v2"}, msg.Tags)
+}
+
+func TestMessage_ValidateInvalidTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		tags  []string
+		field *messageboard.FieldError
+	}{
+		{
+			name:  "too many",
+			tags:  strings.Split("a,b,c,d,e,f,g,h,i,j,k", ","),
+			field: &messageboard.FieldError{Field: "tags", Code: "too_many_tags", Message: `field "tags" must have at most 10 tags`},
+		},
+		{
+			name:  "too long",
+			tags:  []string{strings.Repeat("a", messageboard.MaxTagLength+1)},
+			field: &messageboard.FieldError{Field: "tags", Code: "tag_too_long", Message: `field "tags" must have tags with at most 30 characters`},
+		},
+		{
+			name:  "empty",
+			tags:  []string{"bug", " "},
+			field: &messageboard.FieldError{Field: "tags", Code: "invalid_tag", Message: `field "tags" has the invalid tag "", tags must have only letters, digits, "-" and "_"`},
+		},
+		{
+			name:  "invalid characters",
+			tags:  []string{"bug report"},
+			field: &messageboard.FieldError{Field: "tags", Code: "invalid_tag", Message: `field "tags" has the invalid tag "bug report", tags must have only letters, digits, "-" and "_"`},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &messageboard.Message{
+				Name:  "Guilherme",
+				Email: "xguiga@gmail.com",
+				Text:  "My text",
+				Tags:  tt.tags,
+			}
+			err := msg.Validate()
+
+			var mberr *messageboard.Error
+			require.True(t, errors.As(err, &mberr), "expected *messageboard.Error, got: %v", err)
+			assert.Equal(t, []*messageboard.FieldError{tt.field}, []*messageboard.FieldError(mberr.Fields))
+		})
+	}
+}
+
+func TestMessage_MatchTags(t *testing.T) {
+	msg := &messageboard.Message{Tags: []string{"bug", "urgent"}}
+
+	tests := []struct {
+		filter [][]string
+		match  bool
+	}{
+		{nil, true},
+		{[][]string{{"bug"}}, true},
+		{[][]string{{"crash", "bug"}}, true},
+		{[][]string{{"bug"}, {"urgent"}}, true},
+		{[][]string{{"crash"}}, false},
+		{[][]string{{"bug"}, {"crash"}}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, msg.MatchTags(tt.filter), "%v", tt.filter)
+	}
+}
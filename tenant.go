@@ -0,0 +1,22 @@
+package messageboard
+
+import "regexp"
+
+// DefaultTenant is the tenant of the calls without tenant, its data is kept
+// where it was before tenants exist.
+const DefaultTenant = "default"
+
+// MaxTenantLength is the maximum length of a tenant, it's also part of the
+// database and collection names, which are limited in MongoDB.
+const MaxTenantLength = 40
+
+var tenantRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// ValidTenant returns true if tenant has only lowercase letters, digits and "-",
+// starting with a letter or digit, and it's not too long.
+func ValidTenant(tenant string) bool {
+	return len(tenant) <= MaxTenantLength && tenantRegexp.MatchString(tenant)
+}
+
+// ErrInvalidTenant is returned when the tenant is not valid.
+var ErrInvalidTenant = NewValidationError("invalid_tenant", `tenant must have only lowercase letters, digits and "-", starting with a letter or digit`)
@@ -0,0 +1,41 @@
+package messageboard_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/guilherme-santos/messageboard"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidTenant(t *testing.T) {
+	tests := []struct {
+		tenant string
+		valid  bool
+	}{
+		{"acme", true},
+		{"acme-2", true},
+		{"1acme", true},
+		{strings.Repeat("a", messageboard.MaxTenantLength), true},
+		{strings.Repeat("a", messageboard.MaxTenantLength+1), false},
+		{"", false},
+		{"-acme", false},
+		{"Acme", false},
+		{"acme.corp", false},
+		{"acme_corp", false},
+		{"../acme", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, messageboard.ValidTenant(tt.tenant), tt.tenant)
+	}
+}
+
+func TestTenantFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, messageboard.DefaultTenant, messageboard.TenantFromContext(ctx))
+
+	ctx = messageboard.ContextWithTenant(ctx, "acme")
+	assert.Equal(t, "acme", messageboard.TenantFromContext(ctx))
+}